@@ -0,0 +1,82 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package balancer
+
+import (
+	"math/rand"
+	"sync/atomic"
+)
+
+// Balancer picks one of the replicas for reading.
+type Balancer interface {
+	// Next returns the index of replica picked in [0, n)
+	Next(n int) int
+}
+
+type roundRobin struct {
+	counter uint32
+}
+
+// NewRoundRobin picks replicas in turn
+func NewRoundRobin() Balancer {
+	return &roundRobin{}
+}
+
+func (b *roundRobin) Next(n int) int {
+	return int((atomic.AddUint32(&b.counter, 1) - 1) % uint32(n))
+}
+
+type random struct{}
+
+// NewRandom picks replicas randomly
+func NewRandom() Balancer {
+	return random{}
+}
+
+func (random) Next(n int) int {
+	return rand.Intn(n)
+}
+
+type weighted struct {
+	weights []int
+	total   int
+}
+
+// NewWeighted picks replicas randomly according to weights in the same order of replicas.
+//	Replicas without weight given are weighted as 1.
+func NewWeighted(weights ...int) Balancer {
+	b := &weighted{
+		weights: weights,
+	}
+	for _, w := range weights {
+		if w < 0 {
+			panic("Weight can not be negative")
+		}
+		b.total += w
+	}
+	return b
+}
+
+func (b *weighted) Next(n int) int {
+	total := b.total
+	if n > len(b.weights) {
+		total += n - len(b.weights)
+	}
+	if total <= 0 {
+		return rand.Intn(n)
+	}
+	r := rand.Intn(total)
+	for i := 0; i < n; i++ {
+		w := 1
+		if i < len(b.weights) {
+			w = b.weights[i]
+		}
+		if r < w {
+			return i
+		}
+		r -= w
+	}
+	return n - 1
+}
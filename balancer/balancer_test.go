@@ -0,0 +1,50 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package balancer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundRobin(t *testing.T) {
+	b := NewRoundRobin()
+	assert.Equal(t, 0, b.Next(3))
+	assert.Equal(t, 1, b.Next(3))
+	assert.Equal(t, 2, b.Next(3))
+	assert.Equal(t, 0, b.Next(3))
+	assert.Equal(t, 0, b.Next(1))
+}
+
+func TestRandom(t *testing.T) {
+	b := NewRandom()
+	for i := 0; i < 100; i++ {
+		n := b.Next(3)
+		assert.True(t, n >= 0 && n < 3)
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	b := NewWeighted(0, 3)
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, 1, b.Next(2))
+	}
+
+	// missing weights
+	b = NewWeighted(0)
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, 1, b.Next(2))
+	}
+
+	// all zero
+	b = NewWeighted(0, 0)
+	for i := 0; i < 100; i++ {
+		n := b.Next(2)
+		assert.True(t, n >= 0 && n < 2)
+	}
+
+	assert.Panics(t, func() { NewWeighted(-1) })
+}
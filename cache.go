@@ -0,0 +1,234 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// flightCall is an in-flight or completed loading
+type flightCall struct {
+	wg     sync.WaitGroup
+	result []interface{}
+	err    error
+}
+
+// flightGroup suppresses duplicated loadings of the same key
+//	so that only one of concurrent cache misses reaches database.
+type flightGroup struct {
+	sync.Mutex
+	calls map[string]*flightCall
+}
+
+func (g *flightGroup) do(key string, fn func() ([]interface{}, error)) ([]interface{}, error) {
+	g.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.Unlock()
+		c.wg.Wait()
+		return c.result, c.err
+	}
+	c := &flightCall{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.Unlock()
+
+	c.result, c.err = fn()
+	c.wg.Done()
+
+	g.Lock()
+	delete(g.calls, key)
+	g.Unlock()
+	return c.result, c.err
+}
+
+// generations counts invalidations of tables
+//	so that loadings started before writing never put stale rows into cache.
+type generations struct {
+	sync.Mutex
+	tables map[string]uint64
+}
+
+func (g *generations) get(table string) uint64 {
+	g.Lock()
+	defer g.Unlock()
+	return g.tables[table]
+}
+
+// set calls fn only when the table has not been invalidated since gen was read
+func (g *generations) set(table string, gen uint64, fn func()) {
+	g.Lock()
+	defer g.Unlock()
+	if g.tables[table] == gen {
+		fn()
+	}
+}
+
+// bump invalidates the table through fn and starts a new generation of it
+func (g *generations) bump(table string, fn func()) {
+	g.Lock()
+	defer g.Unlock()
+	if g.tables == nil {
+		g.tables = make(map[string]uint64)
+	}
+	g.tables[table]++
+	fn()
+}
+
+// cacheable returns whether results of selection can be served by cache.
+//	Reading in transaction or from primary always goes to database.
+func (dao *Dao) cacheable(ctx context.Context, primary bool) bool {
+	if dao.cache == nil || primary || IsPrimaryForced(ctx) {
+		return false
+	}
+	_, inTxn := ctx.(*DaoTxnContext)
+	return !inTxn
+}
+
+// cacheTable returns the table under which results are cached.
+//	All shards share the same one.
+func (dao *Dao) cacheTable(ctx context.Context) string {
+	if dao.sharding != nil {
+		return dao.table
+	}
+	return dao.tableOf(ctx)
+}
+
+// cachedSelect returns copies of objects cached by the statement or loaded by fn
+func (dao *Dao) cachedSelect(ctx context.Context, sqlStr string, args []interface{}, fn func() ([]interface{}, error)) ([]interface{}, error) {
+	table := dao.cacheTable(ctx)
+	key := sqlStr + fmt.Sprintf("%#v", cacheArgs(args))
+	if cached, ok := dao.cache.Get(table, key); ok {
+		return dao.copyObjs(cached.([]interface{})), nil
+	}
+	gen := dao.generations.get(table)
+	flightKey := fmt.Sprintf("%s\x00%d\x00%s", table, gen, key)
+	result, err := dao.flight.do(flightKey, func() ([]interface{}, error) {
+		result, err := fn()
+		if err == nil {
+			dao.generations.set(table, gen, func() {
+				dao.cache.Set(table, key, dao.copyObjs(result))
+			})
+		}
+		return result, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dao.copyObjs(result), nil
+}
+
+// invalidate drops results cached of the table written.
+//	Results of loadings still in flight are not cached any more. Writing in transaction
+//	invalidates again after committed, because old rows may be cached by reading outside before committing.
+func (dao *Dao) invalidate(ctx context.Context) {
+	if dao.cache == nil {
+		return
+	}
+	c, g, table := dao.cache, dao.generations, dao.cacheTable(ctx)
+	invalidate := func() {
+		c.Invalidate(table)
+	}
+	g.bump(table, invalidate)
+	if txnCtx, ok := ctx.(*DaoTxnContext); ok {
+		txnCtx.afterCommit(func() {
+			g.bump(table, invalidate)
+		})
+	}
+}
+
+// cacheArgs returns arguments normalised into the values sent to driver,
+//	so that equal queries share the same key whatever pointers are given.
+func cacheArgs(args []interface{}) []interface{} {
+	result := make([]interface{}, len(args))
+	for i, arg := range args {
+		result[i] = cacheArg(arg)
+	}
+	return result
+}
+
+func cacheArg(arg interface{}) interface{} {
+	for {
+		if valuer, ok := arg.(driver.Valuer); ok {
+			if val := reflect.ValueOf(arg); val.Kind() == reflect.Ptr && val.IsNil() {
+				return nil
+			}
+			if v, err := valuer.Value(); err == nil {
+				return v
+			}
+			// the statement fails anyway
+			return arg
+		}
+		val := reflect.ValueOf(arg)
+		if val.Kind() != reflect.Ptr {
+			return arg
+		}
+		if val.IsNil() {
+			return nil
+		}
+		arg = val.Elem().Interface()
+	}
+}
+
+// copyObjs returns deep copies of objects to protect cached ones from modification
+func (dao *Dao) copyObjs(objs []interface{}) []interface{} {
+	if objs == nil {
+		return nil
+	}
+	result := make([]interface{}, len(objs))
+	for i, obj := range objs {
+		result[i] = deepCopy(reflect.ValueOf(obj)).Interface()
+	}
+	return result
+}
+
+// deepCopy returns a copy of val sharing no pointers, slices or maps with it
+func deepCopy(val reflect.Value) reflect.Value {
+	switch val.Kind() {
+	case reflect.Ptr:
+		if val.IsNil() {
+			return val
+		}
+		cp := reflect.New(val.Type().Elem())
+		cp.Elem().Set(deepCopy(val.Elem()))
+		return cp
+	case reflect.Slice:
+		if val.IsNil() {
+			return val
+		}
+		cp := reflect.MakeSlice(val.Type(), val.Len(), val.Len())
+		for i := 0; i < val.Len(); i++ {
+			cp.Index(i).Set(deepCopy(val.Index(i)))
+		}
+		return cp
+	case reflect.Map:
+		if val.IsNil() {
+			return val
+		}
+		cp := reflect.MakeMapWithSize(val.Type(), val.Len())
+		iter := val.MapRange()
+		for iter.Next() {
+			cp.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return cp
+	case reflect.Struct:
+		cp := reflect.New(val.Type()).Elem()
+		cp.Set(val)
+		for i := 0; i < cp.NumField(); i++ {
+			// unexported fields are copied as they are
+			if f := cp.Field(i); f.CanSet() {
+				f.Set(deepCopy(val.Field(i)))
+			}
+		}
+		return cp
+	}
+	return val
+}
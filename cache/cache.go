@@ -0,0 +1,120 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache stores results of queries grouped by table.
+type Cache interface {
+	// Get returns the value cached under key of table
+	Get(table, key string) (interface{}, bool)
+	// Set caches the value under key of table
+	Set(table, key string, value interface{})
+	// Invalidate removes all values of table
+	Invalidate(table string)
+}
+
+type entry struct {
+	table, key string
+	value      interface{}
+	expireAt   time.Time
+}
+
+type lru struct {
+	sync.Mutex
+	size    int
+	ttl     time.Duration
+	ll      *list.List
+	entries map[string]*list.Element
+	tables  map[string]map[string]*list.Element
+}
+
+// NewLRU creates an in-memory cache holding at most size entries.
+//	The least recently used entry is evicted when it's full.
+//	Entries expire after ttl, zero means never.
+func NewLRU(size int, ttl time.Duration) Cache {
+	if size < 1 {
+		panic("Size of cache should be positive")
+	}
+	return &lru{
+		size:    size,
+		ttl:     ttl,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+		tables:  make(map[string]map[string]*list.Element),
+	}
+}
+
+func (c *lru) Get(table, key string) (interface{}, bool) {
+	c.Lock()
+	defer c.Unlock()
+	elem, ok := c.entries[table+"\x00"+key]
+	if !ok {
+		return nil, false
+	}
+	e := elem.Value.(*entry)
+	if !e.expireAt.IsZero() && time.Now().After(e.expireAt) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.ll.MoveToFront(elem)
+	return e.value, true
+}
+
+func (c *lru) Set(table, key string, value interface{}) {
+	c.Lock()
+	defer c.Unlock()
+	var expireAt time.Time
+	if c.ttl > 0 {
+		expireAt = time.Now().Add(c.ttl)
+	}
+	if elem, ok := c.entries[table+"\x00"+key]; ok {
+		e := elem.Value.(*entry)
+		e.value = value
+		e.expireAt = expireAt
+		c.ll.MoveToFront(elem)
+		return
+	}
+	elem := c.ll.PushFront(&entry{
+		table:    table,
+		key:      key,
+		value:    value,
+		expireAt: expireAt,
+	})
+	c.entries[table+"\x00"+key] = elem
+	keys, ok := c.tables[table]
+	if !ok {
+		keys = make(map[string]*list.Element)
+		c.tables[table] = keys
+	}
+	keys[key] = elem
+	for c.ll.Len() > c.size {
+		c.remove(c.ll.Back())
+	}
+}
+
+func (c *lru) Invalidate(table string) {
+	c.Lock()
+	defer c.Unlock()
+	for _, elem := range c.tables[table] {
+		c.remove(elem)
+	}
+}
+
+func (c *lru) remove(elem *list.Element) {
+	e := elem.Value.(*entry)
+	c.ll.Remove(elem)
+	delete(c.entries, e.table+"\x00"+e.key)
+	if keys, ok := c.tables[e.table]; ok {
+		delete(keys, e.key)
+		if len(keys) == 0 {
+			delete(c.tables, e.table)
+		}
+	}
+}
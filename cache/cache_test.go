@@ -0,0 +1,45 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU(t *testing.T) {
+	c := NewLRU(2, 0)
+	c.Set("a", "1", 1)
+	c.Set("a", "2", 2)
+	_, ok := c.Get("a", "1")
+	assert.True(t, ok)
+	// 2 is evicted
+	c.Set("b", "1", 3)
+	_, ok = c.Get("a", "2")
+	assert.False(t, ok)
+	v, ok := c.Get("b", "1")
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+
+	c.Invalidate("a")
+	_, ok = c.Get("a", "1")
+	assert.False(t, ok)
+	_, ok = c.Get("b", "1")
+	assert.True(t, ok)
+
+	assert.Panics(t, func() { NewLRU(0, 0) })
+}
+
+func TestLRUExpiration(t *testing.T) {
+	c := NewLRU(10, 10*time.Millisecond)
+	c.Set("a", "1", 1)
+	_, ok := c.Get("a", "1")
+	assert.True(t, ok)
+	time.Sleep(20 * time.Millisecond)
+	_, ok = c.Get("a", "1")
+	assert.False(t, ok)
+}
@@ -0,0 +1,181 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jasonjoo2010/godao/cache"
+	"github.com/jasonjoo2010/godao/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(Demo{}, db, options.WithCache(cache.NewLRU(100, time.Minute)))
+	ctx := context.Background()
+	sqlStr := "select `id` as `Id`, `name` as `Name`, `value` as `Value`, `cnt` as `Cnt`, `created` as `Created` from `demo` where `id` = ? limit 0, 1;"
+	columns := []string{"Id", "Name", "Value", "Cnt", "Created"}
+
+	mock.ExpectQuery(sqlStr).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "a", "", 1, 0))
+	obj, err := dao.SelectOne(ctx, 1)
+	assert.Nil(t, err)
+	obj.(*Demo).Name = "modified"
+
+	// served by cache and not affected by modification
+	obj, err = dao.SelectOne(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", obj.(*Demo).Name)
+
+	// invalidated by writing
+	mock.ExpectExec("delete from `demo` where `id` = ?").WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = dao.Delete(ctx, 2)
+	assert.Nil(t, err)
+	mock.ExpectQuery(sqlStr).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "b", "", 1, 0))
+	obj, err = dao.SelectOne(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "b", obj.(*Demo).Name)
+
+	// bypassed when reading from primary
+	mock.ExpectQuery(sqlStr).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "c", "", 1, 0))
+	obj, err = dao.SelectOne(ctx, 1, options.WithPrimary())
+	assert.Nil(t, err)
+	assert.Equal(t, "c", obj.(*Demo).Name)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestCacheTxn(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(Demo{}, db, options.WithCache(cache.NewLRU(100, 0)))
+	ctx := context.Background()
+	sqlStr := "select `id` as `Id`, `name` as `Name`, `value` as `Value`, `cnt` as `Cnt`, `created` as `Created` from `demo` where `id` = ? limit 0, 1;"
+	columns := []string{"Id", "Name", "Value", "Cnt", "Created"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("update `demo` set `name` = ?, `value` = ?, `cnt` = ?, `created` = ? where `id` = ?").
+		WithArgs("new", "", 0, int64(0), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// reading outside before committing caches the old row
+	mock.ExpectQuery(sqlStr).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "old", "", 0, 0))
+	mock.ExpectCommit()
+	mock.ExpectQuery(sqlStr).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "new", "", 0, 0))
+
+	txn, err := dao.Txn(nil)
+	assert.Nil(t, err)
+	_, err = dao.Update(txn, &Demo{Id: 1, Name: "new"})
+	assert.Nil(t, err)
+	obj, err := dao.SelectOne(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "old", obj.(*Demo).Name)
+	assert.Nil(t, txn.Commit())
+
+	// invalidated after committed
+	obj, err = dao.SelectOne(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "new", obj.(*Demo).Name)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestCachePointers(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(NullableDemo{}, db, options.WithCache(cache.NewLRU(100, 0)))
+	ctx := context.Background()
+	sqlStr := "select `id` as `Id`, `nick` as `Nick`, `score` as `Score` from `nullable_demo` where `nick` = ?;"
+	columns := []string{"Id", "Nick", "Score"}
+
+	mock.ExpectQuery(sqlStr).WithArgs("a").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "a", 1))
+	mock.ExpectQuery(sqlStr).WithArgs("b").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(2, "b", 2))
+
+	nick, same := "a", "a"
+	list, err := dao.Select(ctx, (&Query{}).Equal("Nick", &nick).Data())
+	assert.Nil(t, err)
+	*list[0].(*NullableDemo).Nick = "modified"
+
+	// keyed by values rather than addresses, and not affected by modification
+	list, err = dao.Select(ctx, (&Query{}).Equal("Nick", &same).Data())
+	assert.Nil(t, err)
+	assert.Equal(t, "a", *list[0].(*NullableDemo).Nick)
+	nick = "b"
+	list, err = dao.Select(ctx, (&Query{}).Equal("Nick", &nick).Data())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), list[0].(*NullableDemo).Id)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestCacheInvalidatedWhileLoading(t *testing.T) {
+	db, _ := mockDB(t)
+	defer db.Close()
+	dao := NewDao(Demo{}, db, options.WithCache(cache.NewLRU(100, 0)))
+	ctx := context.Background()
+	loads := 0
+	load := func(name string, writing bool) func() ([]interface{}, error) {
+		return func() ([]interface{}, error) {
+			loads++
+			if writing {
+				// written by others after the loading started
+				dao.invalidate(ctx)
+			}
+			return []interface{}{&Demo{Id: 1, Name: name}}, nil
+		}
+	}
+
+	result, err := dao.cachedSelect(ctx, "select", []interface{}{1}, load("old", true))
+	assert.Nil(t, err)
+	assert.Equal(t, "old", result[0].(*Demo).Name)
+
+	// stale rows are not cached
+	result, err = dao.cachedSelect(ctx, "select", []interface{}{1}, load("new", false))
+	assert.Nil(t, err)
+	assert.Equal(t, "new", result[0].(*Demo).Name)
+	result, err = dao.cachedSelect(ctx, "select", []interface{}{1}, load("newer", false))
+	assert.Nil(t, err)
+	assert.Equal(t, "new", result[0].(*Demo).Name)
+	assert.Equal(t, 2, loads)
+}
+
+func TestFlightGroup(t *testing.T) {
+	var (
+		g     flightGroup
+		calls int32
+		wg    sync.WaitGroup
+	)
+	start := make(chan struct{})
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			result, err := g.do("k", func() ([]interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(20 * time.Millisecond)
+				return []interface{}{1}, nil
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, []interface{}{1}, result)
+		}()
+	}
+	close(start)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,93 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+// Command godao-cols generates typed column descriptors for model structs,
+// which is designed to be used by go:generate:
+//
+//	//go:generate godao-cols -type Demo
+//
+// It parses all go files in current directory (or given files) and writes
+// `DemoCols` into `<file>_cols.go` where file is the one containing the directive.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jasonjoo2010/godao/internal/codegen"
+)
+
+func main() {
+	var (
+		types  = flag.String("type", "", "comma separated struct names, default is all structs having a primary field")
+		output = flag.String("o", "", "output file, default is <$GOFILE>_cols.go or stdout")
+	)
+	flag.Parse()
+	var names []string
+	if *types != "" {
+		for _, name := range strings.Split(*types, ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+	out := *output
+	if out == "" {
+		if gofile := os.Getenv("GOFILE"); gofile != "" {
+			out = strings.TrimSuffix(gofile, ".go") + "_cols.go"
+		}
+	}
+	files, err := sources(flag.Args(), out)
+	if err == nil {
+		err = write(files, names, out)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// sources returns files given or go files in current directory except tests and output
+func sources(args []string, output string) ([]string, error) {
+	if len(args) > 0 {
+		return args, nil
+	}
+	matches, err := filepath.Glob("*.go")
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(matches))
+	for _, file := range matches {
+		if strings.HasSuffix(file, "_test.go") || file == filepath.Base(output) {
+			continue
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func write(files, names []string, output string) error {
+	if len(files) == 0 {
+		return errors.New("No go file found")
+	}
+	pkg, defs, err := codegen.ParseStructs(files, names...)
+	if err != nil {
+		return err
+	}
+	if len(defs) == 0 {
+		return errors.New("No model struct found")
+	}
+	src, err := codegen.GenerateColumns(pkg, defs)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return ioutil.WriteFile(output, src, 0644)
+}
@@ -0,0 +1,109 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+// Command godao-gen generates model structs from tables in a live MySQL database
+// or from a dump of `CREATE TABLE` statements.
+//
+//	godao-gen -dsn 'user:pass@tcp(127.0.0.1:3306)/db' -tables user,order -o model/models.go
+//	godao-gen -ddl schema.sql -pkg model -dao -o model/models.go
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jasonjoo2010/godao/internal/codegen"
+)
+
+func main() {
+	var (
+		dsn      = flag.String("dsn", "", "data source name of MySQL to read tables from")
+		ddl      = flag.String("ddl", "", "file of CREATE TABLE statements to read tables from")
+		tables   = flag.String("tables", "", "comma separated tables to generate, default is all")
+		pkg      = flag.String("pkg", "model", "package name of generated file")
+		output   = flag.String("o", "", "output file, default is stdout")
+		pointers = flag.Bool("pointers", false, "use pointers for nullable columns instead of sql.Null* types")
+		dao      = flag.Bool("dao", false, "generate typed dao constructors")
+	)
+	flag.Parse()
+	if (*dsn == "") == (*ddl == "") {
+		fmt.Fprintln(os.Stderr, "Either -dsn or -ddl should be specified")
+		flag.Usage()
+		os.Exit(2)
+	}
+	var names []string
+	if *tables != "" {
+		for _, name := range strings.Split(*tables, ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+	defs, err := load(*dsn, *ddl, names)
+	if err == nil {
+		err = write(defs, *output, codegen.ModelOptions{
+			Package:  *pkg,
+			Pointers: *pointers,
+			Dao:      *dao,
+		})
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func load(dsn, ddl string, names []string) ([]codegen.TableDef, error) {
+	if dsn != "" {
+		db, err := sql.Open("mysql", dsn)
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+		return codegen.ReadDB(context.Background(), db, names...)
+	}
+	content, err := ioutil.ReadFile(ddl)
+	if err != nil {
+		return nil, err
+	}
+	defs, err := codegen.ParseDDL(string(content))
+	if err != nil || len(names) == 0 {
+		return defs, err
+	}
+	selected := make([]codegen.TableDef, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, def := range defs {
+			if def.Name == name {
+				selected = append(selected, def)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Table %s not found in %s", name, ddl)
+		}
+	}
+	return selected, nil
+}
+
+func write(defs []codegen.TableDef, output string, opts codegen.ModelOptions) error {
+	if len(defs) == 0 {
+		return errors.New("No table found")
+	}
+	src, err := codegen.GenerateModels(defs, opts)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return ioutil.WriteFile(output, src, 0644)
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+// Command godao-migrate runs versioned SQL migrations in a directory against MySQL.
+//
+//	godao-migrate -dsn 'user:pass@tcp(127.0.0.1:3306)/db' -dir ./migrations up
+//	godao-migrate -dsn ... -dir ... down
+//	godao-migrate -dsn ... -dir ... goto 3
+//	godao-migrate -dsn ... -dir ... status
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jasonjoo2010/godao/migration"
+)
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] up | down | goto <version> | status\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+func main() {
+	var (
+		dsn   = flag.String("dsn", "", "data source name of MySQL")
+		dir   = flag.String("dir", "migrations", "directory of migration files")
+		table = flag.String("table", "schema_migrations", "bookkeeping table")
+	)
+	flag.Usage = usage
+	flag.Parse()
+	if *dsn == "" || flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+	if err := run(*dsn, *dir, *table, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(dsn, dir, table string, args []string) error {
+	migrations, err := migration.LoadDir(dir)
+	if err != nil {
+		return err
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	m := migration.New(db, migrations, migration.WithTable(table))
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		return m.Up(ctx)
+	case "down":
+		return m.Down(ctx)
+	case "goto":
+		if len(args) < 2 {
+			return errors.New("Version is required by goto")
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return err
+		}
+		return m.Goto(ctx, version)
+	case "status":
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range status {
+			state := "pending"
+			switch {
+			case s.Missing:
+				state = "applied at " + time.Unix(s.AppliedAt, 0).Format(time.RFC3339) + " (missing)"
+			case s.Applied:
+				state = "applied at " + time.Unix(s.AppliedAt, 0).Format(time.RFC3339)
+			}
+			fmt.Printf("%d\t%s\t%s\n", s.Version, s.Name, state)
+		}
+		return nil
+	}
+	return fmt.Errorf("Unknown command: %s", args[0])
+}
@@ -0,0 +1,179 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jasonjoo2010/godao/options"
+	"github.com/jasonjoo2010/godao/query"
+	"github.com/jasonjoo2010/godao/types"
+)
+
+// CompiledQuery is a query validated and generated once by Dao.Compile()
+//	which can be executed repeatedly with fresh bind values.
+type CompiledQuery struct {
+	dao     *Dao
+	primary bool
+	fields  []*types.ModelField
+
+	// generated statements without table
+	selectPrefix, selectSuffix string
+	countSuffix                string
+
+	// arguments of scopes prepended to the fresh ones
+	scopeArgs []interface{}
+	// operators of placeholders, through which patterns of `like` are bound
+	ops []query.Op
+}
+
+// Compile validates fields in data and generates statements for it.
+//	Values in data are placeholders only, fresh bind values should be given
+//	in the order of placeholders when executing, e.g. every element of `In`.
+//	Values of `Like` / `StartsWith` / `EndsWith` are wrapped by wildcards as the query is.
+//	Scopes and tenant of dao are applied as well.
+func (dao *Dao) Compile(data query.Data, opts ...options.SelectOption) (cq *CompiledQuery, err error) {
+	if dao.sharding != nil {
+		return nil, errors.New("Compiling is not supported for sharding dao")
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			cq = nil
+			err = fmt.Errorf("Compile query failed: %v", r)
+		}
+	}()
+	cfg := options.SelectOptions{}
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+	if len(cfg.Fields) == 0 {
+		cfg.Fields = dao.selectColumns
+	}
+	_, args := query.ConditionSQL(dao.fieldMap, dao.columnMap, &data)
+	cq = &CompiledQuery{
+		dao:     dao,
+		primary: cfg.Primary,
+		ops:     query.ArgOps(&data),
+	}
+	if dao.scoped() {
+		conditions, children := dao.scopeConditions(nil)
+		inner := data
+		inner.Order = nil
+		inner.Offset = 0
+		inner.Limit = 0
+		data = query.Data{
+			Conditions: conditions,
+			Children:   append(children, inner),
+			Order:      data.Order,
+			Offset:     data.Offset,
+			Limit:      data.Limit,
+		}
+		_, args = query.ConditionSQL(dao.fieldMap, dao.columnMap, &data)
+		cq.scopeArgs = args[:len(args)-len(cq.ops)]
+	}
+	condition, _ := query.ConditionSQL(dao.fieldMap, dao.columnMap, &data)
+	whereData := data
+	whereData.Order = nil
+	whereData.Offset = 0
+	whereData.Limit = 0
+	where, _ := query.ConditionSQL(dao.fieldMap, dao.columnMap, &whereData)
+
+	var sqlSelect string
+	sqlSelect, cq.fields = options.GenerateSelectFields(cfg.Fields, dao.fieldMap, dao.columnMap)
+	cq.selectPrefix = "select " + sqlSelect + " from `"
+	cq.selectSuffix = "`"
+	if condition != "" {
+		cq.selectSuffix += " " + condition
+	}
+	cq.selectSuffix += ";"
+	cq.countSuffix = "`"
+	if where != "" {
+		cq.countSuffix += " " + where
+	}
+	return cq, nil
+}
+
+// MustCompile is like Compile but panics if the query can't be compiled.
+//	It's convenient to catch mistakes at startup.
+func (dao *Dao) MustCompile(data query.Data, opts ...options.SelectOption) *CompiledQuery {
+	cq, err := dao.Compile(data, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return cq
+}
+
+// bind returns the arguments of scopes and fresh bind values
+func (cq *CompiledQuery) bind(ctx context.Context, args []interface{}) ([]interface{}, error) {
+	if len(args) != len(cq.ops) {
+		return nil, fmt.Errorf("%d bind values expected but %d given", len(cq.ops), len(args))
+	}
+	values := make([]interface{}, 0, len(cq.scopeArgs)+len(args))
+	values = append(values, cq.scopeArgs...)
+	for i, arg := range args {
+		switch op := cq.ops[i]; op {
+		case query.OpLike, query.OpStartsWith, query.OpEndsWith:
+			val, ok := arg.(string)
+			if !ok {
+				return nil, fmt.Errorf("Bind value %d of `like` should be a string", i)
+			}
+			arg = op.Pattern(val)
+		}
+		values = append(values, arg)
+	}
+	if len(cq.scopeArgs) == 0 {
+		return values, nil
+	}
+	if cq.dao.tenant != nil {
+		tenant, err := cq.dao.tenantOf(ctx)
+		if err != nil {
+			return nil, err
+		}
+		values[0] = tenant
+	}
+	return values, nil
+}
+
+// Select returns objects matched by the compiled query with fresh bind values
+func (cq *CompiledQuery) Select(ctx context.Context, args ...interface{}) (result []interface{}, err error) {
+	dao := cq.dao
+	ctx, finish := dao.observe(ctx, "Select")
+	defer finish(&err)
+	values, err := cq.bind(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	table := dao.tableOf(ctx)
+	sqlStr := cq.selectPrefix + table + cq.selectSuffix
+	fn := func() (result []interface{}, err error) {
+		e := dao.readExecutor(ctx, cq.primary)
+		_, err = dao.query(ctx, e, types.OperationSelect, dao.collector(ctx, cq.fields, &result), table, sqlStr, values...)
+		if err != nil {
+			return nil, err
+		}
+		return
+	}
+	if dao.cacheable(ctx, cq.primary) {
+		return dao.cachedSelect(ctx, sqlStr, values, fn)
+	}
+	return fn()
+}
+
+// Count returns count of rows matched by the compiled query with fresh bind values
+func (cq *CompiledQuery) Count(ctx context.Context, args ...interface{}) (cnt int64, err error) {
+	dao := cq.dao
+	ctx, finish := dao.observe(ctx, "Count")
+	defer finish(&err)
+	values, err := cq.bind(ctx, args)
+	if err != nil {
+		return
+	}
+	table := dao.tableOf(ctx)
+	e := dao.readExecutor(ctx, cq.primary)
+	_, err = dao.queryRow(ctx, e, types.OperationAggregate, []interface{}{&cnt}, table, "select count(*) from `"+table+cq.countSuffix, values...)
+	return
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jasonjoo2010/godao/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompile(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(Demo{}, db)
+	ctx := context.Background()
+
+	_, err := dao.Compile((&Query{}).Equal("Unknown", 0).Data())
+	assert.NotNil(t, err)
+	assert.Panics(t, func() { dao.MustCompile((&Query{}).OrderBy("Unknown", false).Data()) })
+
+	cq, err := dao.Compile((&Query{}).Equal("Name", "").Greater("Cnt", 0).OrderBy("Id", true).Limit(10).Data(), options.WithFields("Id", "Name"))
+	assert.Nil(t, err)
+	for _, name := range []string{"a", "b"} {
+		mock.ExpectQuery("select `id` as `Id`, `name` as `Name` from `demo` where `name` = ? and `cnt` > ? order by `id` desc limit 0, 10;").
+			WithArgs(name, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"Id", "Name"}).AddRow(1, name))
+		list, err := cq.Select(ctx, name, 1)
+		assert.Nil(t, err)
+		assert.Equal(t, name, list[0].(*Demo).Name)
+	}
+	mock.ExpectQuery("select count(*) from `demo` where `name` = ? and `cnt` > ?").
+		WithArgs("c", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"cnt"}).AddRow(3))
+	cnt, err := cq.Count(ctx, "c", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), cnt)
+
+	_, err = cq.Select(ctx, "a")
+	assert.NotNil(t, err)
+
+	// wildcards are added as the query does
+	cq = dao.MustCompile((&Query{}).StartsWith("Name", "").In("Id", []interface{}{0, 0}).Data(), options.WithFields("Id"))
+	mock.ExpectQuery("select `id` as `Id` from `demo` where `name` like ? and `id` in (?, ?);").
+		WithArgs("ab%", 1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1))
+	_, err = cq.Select(ctx, "ab", 1, 2)
+	assert.Nil(t, err)
+	_, err = cq.Select(ctx, 3, 1, 2)
+	assert.NotNil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestCompileScoped(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(TenantDemo{}, db, options.WithDefaultScope((&Query{}).NotEqual("Name", "").Data()))
+
+	cq, err := dao.Compile((&Query{}).Equal("Id", 0).Equal("Name", "").Or().Data())
+	assert.Nil(t, err)
+	mock.ExpectQuery("select `id` as `Id`, `tenant_id` as `TenantId`, `name` as `Name` from `tenant_demo` where `tenant_id` = ? and `name` <> ? and (`id` = ? or `name` = ?);").
+		WithArgs(int64(7), "", 1, "a").
+		WillReturnRows(sqlmock.NewRows([]string{"Id", "TenantId", "Name"}).AddRow(1, 7, "a"))
+	list, err := cq.Select(WithTenant(context.Background(), int64(7)), 1, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(list))
+
+	_, err = cq.Select(context.Background(), 1, "a")
+	assert.Equal(t, ErrNoTenant, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -10,17 +10,31 @@ import (
 	"errors"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/jasonjoo2010/enhanced-utils/strutils"
+	"github.com/jasonjoo2010/godao/balancer"
+	"github.com/jasonjoo2010/godao/cache"
 	"github.com/jasonjoo2010/godao/model"
 	"github.com/jasonjoo2010/godao/options"
 	"github.com/jasonjoo2010/godao/query"
+	"github.com/jasonjoo2010/godao/sharding"
 	"github.com/jasonjoo2010/godao/types"
 	"github.com/sirupsen/logrus"
 )
 
 const (
-	internal_TXN = "__TXN__"
+	internal_TXN     = "__TXN__"
+	internal_TXN_DB  = "__TXN_DB__"
+	internal_TXN_CB  = "__TXN_CB__"
+	internal_CALL    = "__CALL__"
+	internal_PRIMARY = "__PRIMARY__"
+	internal_TABLE   = "__TABLE__"
+)
+
+var (
+	// ErrStaleObject is returned when updating an object whose version has been changed by others.
+	ErrStaleObject = errors.New("Object is stale, version mismatched")
 )
 
 type DaoTxnContext struct {
@@ -31,16 +45,108 @@ func (ctx *DaoTxnContext) Txn() *sql.Tx {
 	return ctx.Value(internal_TXN).(*sql.Tx)
 }
 
+// txnCallbacks holds functions invoked after the transaction committed
+type txnCallbacks struct {
+	sync.Mutex
+	fns []func()
+}
+
+// afterCommit registers fn to be invoked after committed through Commit()
+func (ctx *DaoTxnContext) afterCommit(fn func()) {
+	cb, ok := ctx.Value(internal_TXN_CB).(*txnCallbacks)
+	if !ok {
+		return
+	}
+	cb.Lock()
+	cb.fns = append(cb.fns, fn)
+	cb.Unlock()
+}
+
+// Commit commits the transaction and then invalidates results cached of tables written in it.
+//	Prefer it to Txn().Commit() when caching is enabled.
+func (ctx *DaoTxnContext) Commit() error {
+	if err := ctx.Txn().Commit(); err != nil {
+		return err
+	}
+	if cb, ok := ctx.Value(internal_TXN_CB).(*txnCallbacks); ok {
+		cb.Lock()
+		fns := cb.fns
+		cb.fns = nil
+		cb.Unlock()
+		for _, fn := range fns {
+			fn()
+		}
+	}
+	return nil
+}
+
+// Rollback rolls back the transaction
+func (ctx *DaoTxnContext) Rollback() error {
+	if cb, ok := ctx.Value(internal_TXN_CB).(*txnCallbacks); ok {
+		cb.Lock()
+		cb.fns = nil
+		cb.Unlock()
+	}
+	return ctx.Txn().Rollback()
+}
+
+// withValue wraps the value into context keeping the transaction if there is
+func withValue(ctx context.Context, key, val interface{}) context.Context {
+	if txnCtx, ok := ctx.(*DaoTxnContext); ok {
+		return &DaoTxnContext{context.WithValue(txnCtx.Context, key, val)}
+	}
+	return context.WithValue(ctx, key, val)
+}
+
+// ForcePrimary returns a context through which all reading will be sent to primary
+func ForcePrimary(ctx context.Context) context.Context {
+	return withValue(ctx, internal_PRIMARY, true)
+}
+
+// IsPrimaryForced returns whether reading is forced to primary in the context
+func IsPrimaryForced(ctx context.Context) bool {
+	forced, _ := ctx.Value(internal_PRIMARY).(bool)
+	return forced
+}
+
+// OverrideTable returns a context through which daos operate on the specific table
+//	instead of their own, e.g. monthly partitioned table `log_202610`.
+//	It's ignored by sharding daos.
+func OverrideTable(ctx context.Context, table string) context.Context {
+	return withValue(ctx, internal_TABLE, table)
+}
+
 type Dao struct {
 	db        *sql.DB
+	replicas  []*sql.DB
+	balancer  balancer.Balancer
 	table     string
+	sharding  sharding.Strategy
+	shardKey  *types.ModelField
 	modelType reflect.Type
 
+	// scopes
+	scopes      []query.Data
+	namedScopes map[string]query.Data
+
 	// fields
 	primaries []*types.ModelField
+	version   *types.ModelField
+	tenant    *types.ModelField
+	tenantPos int
 	fieldMap  map[string]*types.ModelField
 	columnMap map[string]*types.ModelField
 	fields    []*types.ModelField
+	hooks     modelHooks
+
+	interceptors []types.Interceptor
+	observers    []types.CallObserver
+	logger       types.Logger
+
+	cache       cache.Cache
+	flight      *flightGroup
+	generations *generations
+	stmts       *stmtCache
 
 	// cache
 	selectColumns            []string
@@ -49,6 +155,7 @@ type Dao struct {
 
 // NewDao creates a dao object based on given model type.
 //	It should be reused as possible to keep efficient.
+//	db is the primary for writing, and replicas for reading can be specified by options.WithReplicas().
 func NewDao(m interface{}, db *sql.DB, opts ...options.DaoOption) *Dao {
 	dao := &Dao{
 		db: db,
@@ -58,12 +165,38 @@ func NewDao(m interface{}, db *sql.DB, opts ...options.DaoOption) *Dao {
 	for _, fn := range opts {
 		fn(&cfg)
 	}
+	dao.replicas = cfg.Replicas
+	dao.scopes = cfg.DefaultScopes
+	dao.namedScopes = cfg.Scopes
+	dao.cache = cfg.Cache
+	dao.flight = &flightGroup{}
+	dao.generations = &generations{}
+	if cfg.StmtCacheSize > 0 {
+		dao.stmts = newStmtCache(cfg.StmtCacheSize)
+	}
+	dao.balancer = cfg.Balancer
+	if dao.balancer == nil {
+		dao.balancer = balancer.NewRoundRobin()
+	}
 	if cfg.Table != "" {
 		dao.table = cfg.Table
 	} else {
 		dao.table = strutils.ToUnderscore(model.ParseTableName(m))
 	}
+	dao.logger = cfg.Logger
+	if dao.logger == nil {
+		dao.logger = NewLogrusLogger()
+	}
+	dao.interceptors = cfg.Interceptors
+	dao.observers = cfg.Observers
+	if cfg.Metrics != nil {
+		if _, ok := cfg.Metrics.(types.NoopMetricsCollector); !ok {
+			dao.interceptors = append(dao.interceptors, metricsInterceptor(cfg.Metrics))
+		}
+	}
+	dao.interceptors = append(dao.interceptors, loggingInterceptor(dao.logger, &cfg))
 	dao.modelType = model.RealType(m)
+	dao.hooks = parseHooks(dao.modelType)
 	// fields
 	fields := model.Parse(m)
 	if len(fields) < 1 {
@@ -76,12 +209,46 @@ func NewDao(m interface{}, db *sql.DB, opts ...options.DaoOption) *Dao {
 	columnsBuilder := strings.Builder{}
 	holderBuilder := strings.Builder{}
 	selectFields := make([]string, 0, len(fields))
-	for _, field := range fields {
+	for i, field := range fields {
 		dao.columnMap[field.Column] = field
 		dao.fieldMap[field.Name] = field
 		if field.Primary {
 			dao.primaries = append(dao.primaries, field)
 		}
+		if field.Version {
+			if dao.version != nil {
+				panic("Only one version field is allowed")
+			}
+			if field.Primary {
+				panic("Version field can not be primary")
+			}
+			switch field.Type.Kind() {
+			case
+				reflect.Int,
+				reflect.Int8,
+				reflect.Int16,
+				reflect.Int32,
+				reflect.Int64,
+				reflect.Uint,
+				reflect.Uint8,
+				reflect.Uint16,
+				reflect.Uint32,
+				reflect.Uint64:
+			default:
+				panic("Version field should be an integer")
+			}
+			dao.version = field
+		}
+		if field.Tenant {
+			if dao.tenant != nil {
+				panic("Only one tenant field is allowed")
+			}
+			if field.Primary {
+				panic("Tenant field can not be primary")
+			}
+			dao.tenant = field
+			dao.tenantPos = i
+		}
 		{
 			if columnsBuilder.Len() > 0 {
 				columnsBuilder.WriteString(", ")
@@ -97,12 +264,41 @@ func NewDao(m interface{}, db *sql.DB, opts ...options.DaoOption) *Dao {
 	if len(dao.primaries) < 1 {
 		panic("No primary key found")
 	}
+	if cfg.Sharding != nil {
+		column := query.GetColumn(cfg.Sharding.Field(), dao.fieldMap, dao.columnMap, false)
+		if column == "" {
+			panic("Shard key not found: " + cfg.Sharding.Field())
+		}
+		dao.sharding = cfg.Sharding
+		dao.shardKey = dao.columnMap[column]
+	}
 	dao.columnsAll = columnsBuilder.String()
 	dao.valuesHolder = holderBuilder.String()
 	dao.selectColumns = selectFields
+	if cfg.Verify {
+		if err := dao.Verify(context.Background()); err != nil {
+			panic(err)
+		}
+	}
 	return dao
 }
 
+// WithTable returns a cheap copy of dao operating on another table in the same structure.
+//	Parsed fields and cached fragments are shared.
+func (dao *Dao) WithTable(table string) *Dao {
+	clone := *dao
+	clone.table = table
+	return &clone
+}
+
+// tableOf returns the table overridden in context or the table of dao
+func (dao *Dao) tableOf(ctx context.Context) string {
+	if table, ok := ctx.Value(internal_TABLE).(string); ok && table != "" {
+		return table
+	}
+	return dao.table
+}
+
 // Txn creates a new transaction and wraps it in a context
 // which can be used in following invocations.
 func (dao *Dao) Txn(opts *sql.TxOptions) (*DaoTxnContext, error) {
@@ -116,12 +312,16 @@ func (dao *Dao) TxnWithContext(ctx context.Context, opts *sql.TxOptions) (*DaoTx
 	if err != nil {
 		return nil, err
 	}
+	ctx = context.WithValue(ctx, internal_TXN_DB, dao.db)
+	ctx = context.WithValue(ctx, internal_TXN_CB, &txnCallbacks{})
 	return &DaoTxnContext{context.WithValue(ctx, internal_TXN, tx)}, nil
 }
 
 // SelectOne returns the row or nil specified by primary.
 // Union primaries are not supported. Please use SelectOneByCondition
-func (dao *Dao) SelectOne(ctx context.Context, id interface{}, opts ...options.SelectOption) (interface{}, error) {
+func (dao *Dao) SelectOne(ctx context.Context, id interface{}, opts ...options.SelectOption) (obj interface{}, err error) {
+	ctx, finish := dao.observe(ctx, "SelectOne")
+	defer finish(&err)
 	if len(dao.primaries) != 1 {
 		panic("SelectOne only support single primary key model: " + dao.table)
 	}
@@ -133,7 +333,9 @@ func (dao *Dao) SelectOne(ctx context.Context, id interface{}, opts ...options.S
 		opts...)
 }
 
-func (dao *Dao) SelectOneByCondition(ctx context.Context, data query.Data, opts ...options.SelectOption) (interface{}, error) {
+func (dao *Dao) SelectOneByCondition(ctx context.Context, data query.Data, opts ...options.SelectOption) (obj interface{}, err error) {
+	ctx, finish := dao.observe(ctx, "SelectOneByCondition")
+	defer finish(&err)
 	if data.Limit != 1 {
 		data.Limit = 1
 	}
@@ -147,7 +349,9 @@ func (dao *Dao) SelectOneByCondition(ctx context.Context, data query.Data, opts
 	return rows[0], nil
 }
 
-func (dao *Dao) SelectOneBy(ctx context.Context, name string, val interface{}, opts ...options.SelectOption) (interface{}, error) {
+func (dao *Dao) SelectOneBy(ctx context.Context, name string, val interface{}, opts ...options.SelectOption) (obj interface{}, err error) {
+	ctx, finish := dao.observe(ctx, "SelectOneBy")
+	defer finish(&err)
 	return dao.SelectOneByCondition(ctx,
 		(&Query{}).
 			Equal(name, val).
@@ -156,65 +360,150 @@ func (dao *Dao) SelectOneBy(ctx context.Context, name string, val interface{}, o
 		opts...)
 }
 
+// fetchObj scans a row into a new object.
 func (dao *Dao) fetchObj(rows *sql.Rows, fields []*types.ModelField) (obj interface{}, err error) {
-	args := make([]interface{}, len(fields))
 	val := reflect.New(dao.modelType)
-	for i, f := range fields {
-		args[i] = val.Elem().Field(f.Index).Addr().Interface()
-	}
-	err = rows.Scan(args...)
-	if err == nil {
+	if err = scanFields(rows, val.Elem(), fields); err == nil {
 		obj = val.Interface()
 	}
 	return
 }
 
+// scanFields scans a row into fields of struct value.
+//	Pointer fields get nil for NULL, and `nullzero` fields are scanned through
+//	pointers then NULL remains the zero value.
+func scanFields(rows *sql.Rows, val reflect.Value, fields []*types.ModelField) error {
+	args := make([]interface{}, len(fields))
+	var nullZeros map[int]reflect.Value
+	for i, f := range fields {
+		field := val.Field(f.Index)
+		if f.NullZero && field.Kind() != reflect.Ptr {
+			if nullZeros == nil {
+				nullZeros = make(map[int]reflect.Value)
+			}
+			holder := reflect.New(reflect.PtrTo(field.Type()))
+			nullZeros[i] = holder
+			args[i] = holder.Interface()
+			continue
+		}
+		args[i] = field.Addr().Interface()
+	}
+	if err := rows.Scan(args...); err != nil {
+		return err
+	}
+	for i, holder := range nullZeros {
+		if ptr := holder.Elem(); !ptr.IsNil() {
+			val.Field(fields[i].Index).Set(ptr.Elem())
+		}
+	}
+	return nil
+}
+
+// collector returns the function fetching objects from rows into result
+func (dao *Dao) collector(ctx context.Context, fields []*types.ModelField, result *[]interface{}) func(rows *sql.Rows) error {
+	return func(rows *sql.Rows) error {
+		obj, err := dao.fetchObj(rows, fields)
+		if err != nil {
+			dao.logger.Warn("Convert object failed", err)
+			return nil
+		}
+		if dao.hooks.afterSelect {
+			if err := obj.(AfterSelecter).AfterSelect(ctx); err != nil {
+				return err
+			}
+		}
+		*result = append(*result, obj)
+		return nil
+	}
+}
+
+// Select returns objects matched by the condition.
+//	For a sharding dao, query without shard key will be scattered into all shards
+//	and results are merged by order, offset and limit in memory.
+//	Fields ordered by are selected as well for merging.
 func (dao *Dao) Select(ctx context.Context, data query.Data, opts ...options.SelectOption) (result []interface{}, err error) {
+	ctx, finish := dao.observe(ctx, "Select")
+	defer finish(&err)
 	cfg := options.SelectOptions{}
 	for _, fn := range opts {
 		fn(&cfg)
 	}
-	condition, args := query.ConditionSQL(dao.fieldMap, dao.columnMap, &data)
-	sqlBuilder := strings.Builder{}
-	sqlBuilder.WriteString("select ")
+	data, err = dao.applyScopes(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	shards, err := dao.route(ctx, &data)
+	if err != nil {
+		return nil, err
+	}
+	scattered := data
+	if len(shards) > 1 && data.Limit > 0 {
+		scattered.Offset = 0
+		scattered.Limit = data.Offset + data.Limit
+	}
+	condition, args := query.ConditionSQL(dao.fieldMap, dao.columnMap, &scattered)
 	if len(cfg.Fields) == 0 {
 		cfg.Fields = dao.selectColumns
 	}
 	sqlSelect, fieldsSelect := options.GenerateSelectFields(cfg.Fields, dao.fieldMap, dao.columnMap)
-	sqlBuilder.WriteString(sqlSelect)
-	sqlBuilder.WriteString(" from `")
-	sqlBuilder.WriteString(dao.table)
-	sqlBuilder.WriteString("`")
-	if condition != "" {
-		sqlBuilder.WriteString(" ")
-		sqlBuilder.WriteString(condition)
+	if len(shards) > 1 {
+		if missing := dao.unselectedOrders(&data, fieldsSelect); len(missing) > 0 {
+			// merging needs values of fields ordered by
+			cfg.Fields = append(append([]string(nil), cfg.Fields...), missing...)
+			sqlSelect, fieldsSelect = options.GenerateSelectFields(cfg.Fields, dao.fieldMap, dao.columnMap)
+		}
 	}
-	sqlBuilder.WriteString(";")
 
-	var rows *sql.Rows
-	if txnCtx, ok := ctx.(*DaoTxnContext); ok {
-		rows, err = txnCtx.Txn().Query(sqlBuilder.String(), args...)
-	} else {
-		rows, err = dao.db.Query(sqlBuilder.String(), args...)
+	if dao.cacheable(ctx, cfg.Primary) {
+		return dao.cachedSelect(ctx, "select "+sqlSelect+" "+condition, args, func() ([]interface{}, error) {
+			return dao.selectShards(ctx, shards, &data, &cfg, sqlSelect, fieldsSelect, condition, args)
+		})
 	}
-	if err != nil {
-		return
-	}
-	defer rows.Close()
+	return dao.selectShards(ctx, shards, &data, &cfg, sqlSelect, fieldsSelect, condition, args)
+}
 
-	for rows.Next() {
-		obj, err := dao.fetchObj(rows, fieldsSelect)
+// selectShards queries every shard and merges the results
+func (dao *Dao) selectShards(
+	ctx context.Context,
+	shards []sharding.Shard,
+	data *query.Data,
+	cfg *options.SelectOptions,
+	sqlSelect string,
+	fieldsSelect []*types.ModelField,
+	condition string,
+	args []interface{},
+) (result []interface{}, err error) {
+	for _, shard := range shards {
+		e, err := dao.shardReadExecutor(ctx, shard, cfg.Primary)
 		if err != nil {
-			logrus.Warn("Convert object failed: ", err.Error())
-			continue
+			return nil, err
 		}
-		result = append(result, obj)
-	}
+		sqlBuilder := strings.Builder{}
+		sqlBuilder.WriteString("select ")
+		sqlBuilder.WriteString(sqlSelect)
+		sqlBuilder.WriteString(" from `")
+		sqlBuilder.WriteString(shard.Table)
+		sqlBuilder.WriteString("`")
+		if condition != "" {
+			sqlBuilder.WriteString(" ")
+			sqlBuilder.WriteString(condition)
+		}
+		sqlBuilder.WriteString(";")
 
+		_, err = dao.query(ctx, e, types.OperationSelect, dao.collector(ctx, fieldsSelect, &result), shard.Table, sqlBuilder.String(), args...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(shards) > 1 {
+		result = dao.mergeShards(result, data)
+	}
 	return
 }
 
-func (dao *Dao) SelectBy(ctx context.Context, name string, val interface{}, limit int, opts ...options.SelectOption) ([]interface{}, error) {
+func (dao *Dao) SelectBy(ctx context.Context, name string, val interface{}, limit int, opts ...options.SelectOption) (result []interface{}, err error) {
+	ctx, finish := dao.observe(ctx, "SelectBy")
+	defer finish(&err)
 	return dao.Select(ctx,
 		(&Query{}).
 			Equal(name, val).
@@ -223,40 +512,71 @@ func (dao *Dao) SelectBy(ctx context.Context, name string, val interface{}, limi
 		opts...)
 }
 
-func (dao *Dao) aggregate(ctx context.Context, data query.Data, aggregation string, values ...interface{}) (err error) {
-	conditionSQL, args := query.ConditionSQL(dao.fieldMap, dao.columnMap, &data)
-
-	sqlBuilder := strings.Builder{}
-	sqlBuilder.WriteString("select ")
-	sqlBuilder.WriteString(aggregation)
-	sqlBuilder.WriteString(" from `")
-	sqlBuilder.WriteString(dao.table)
-	sqlBuilder.WriteString("`")
-	if conditionSQL != "" {
-		sqlBuilder.WriteString(" ")
-		sqlBuilder.WriteString(conditionSQL)
-	}
+// aggregate scans the aggregation of every shard matched into values and invokes fn to accumulate.
+//	Shards returning no row, e.g. checking existence, are skipped without invoking fn.
+func (dao *Dao) aggregate(ctx context.Context, data query.Data, aggregation string, values []interface{}, fn func()) (err error) {
+	return dao.aggregateUntil(ctx, data, aggregation, values, func() bool {
+		fn()
+		return false
+	})
+}
 
-	var row *sql.Row
-	if txnCtx, ok := ctx.(*DaoTxnContext); ok {
-		row = txnCtx.Txn().QueryRow(sqlBuilder.String(), args...)
-	} else {
-		row = dao.db.QueryRow(sqlBuilder.String(), args...)
+// aggregateUntil is like aggregate but stops querying the rest shards once fn returns true
+func (dao *Dao) aggregateUntil(ctx context.Context, data query.Data, aggregation string, values []interface{}, fn func() bool) (err error) {
+	data, err = dao.applyScopes(ctx, data)
+	if err != nil {
+		return
 	}
+	shards, err := dao.route(ctx, &data)
 	if err != nil {
 		return
 	}
+	conditionSQL, args := query.ConditionSQL(dao.fieldMap, dao.columnMap, &data)
+
+	for _, shard := range shards {
+		e, err := dao.shardReadExecutor(ctx, shard, false)
+		if err != nil {
+			return err
+		}
+		sqlBuilder := strings.Builder{}
+		sqlBuilder.WriteString("select ")
+		sqlBuilder.WriteString(aggregation)
+		sqlBuilder.WriteString(" from `")
+		sqlBuilder.WriteString(shard.Table)
+		sqlBuilder.WriteString("`")
+		if conditionSQL != "" {
+			sqlBuilder.WriteString(" ")
+			sqlBuilder.WriteString(conditionSQL)
+		}
 
-	err = row.Scan(values...)
+		done := false
+		_, err = dao.query(ctx, e, types.OperationAggregate, func(rows *sql.Rows) error {
+			if err := rows.Scan(values...); err != nil {
+				return err
+			}
+			done = fn()
+			return nil
+		}, shard.Table, sqlBuilder.String(), args...)
+		if err != nil || done {
+			return err
+		}
+	}
 	return
 }
 
 func (dao *Dao) Count(ctx context.Context, data query.Data) (cnt int64, err error) {
-	err = dao.aggregate(ctx, data, "count(*)", &cnt)
+	ctx, finish := dao.observe(ctx, "Count")
+	defer finish(&err)
+	var val int64
+	err = dao.aggregate(ctx, data, "count(*)", []interface{}{&val}, func() {
+		cnt += val
+	})
 	return
 }
 
-func (dao *Dao) CountBy(ctx context.Context, name string, val interface{}) (int64, error) {
+func (dao *Dao) CountBy(ctx context.Context, name string, val interface{}) (cnt int64, err error) {
+	ctx, finish := dao.observe(ctx, "CountBy")
+	defer finish(&err)
 	return dao.Count(ctx,
 		(&Query{}).
 			Equal(name, val).
@@ -264,7 +584,34 @@ func (dao *Dao) CountBy(ctx context.Context, name string, val interface{}) (int6
 	)
 }
 
-func (dao *Dao) Sum(ctx context.Context, name string, data query.Data) (interface{}, error) {
+// Exists checks whether any row matches the condition by `select 1 ... limit 1`
+//	without counting or loading rows.
+func (dao *Dao) Exists(ctx context.Context, data query.Data) (exists bool, err error) {
+	ctx, finish := dao.observe(ctx, "Exists")
+	defer finish(&err)
+	data.Order = nil
+	data.Offset, data.Limit = 0, 1
+	var val int64
+	err = dao.aggregateUntil(ctx, data, "1", []interface{}{&val}, func() bool {
+		exists = true
+		return true
+	})
+	return
+}
+
+func (dao *Dao) ExistsBy(ctx context.Context, name string, val interface{}) (exists bool, err error) {
+	ctx, finish := dao.observe(ctx, "ExistsBy")
+	defer finish(&err)
+	return dao.Exists(ctx,
+		(&Query{}).
+			Equal(name, val).
+			Data(),
+	)
+}
+
+func (dao *Dao) Sum(ctx context.Context, name string, data query.Data) (sum interface{}, err error) {
+	ctx, finish := dao.observe(ctx, "Sum")
+	defer finish(&err)
 	columnName := query.GetColumn(name, dao.fieldMap, dao.columnMap, true)
 	field := dao.columnMap[columnName]
 	fieldSelect := "sum(`" + field.Column + "`)"
@@ -280,28 +627,59 @@ func (dao *Dao) Sum(ctx context.Context, name string, data query.Data) (interfac
 		reflect.Uint16,
 		reflect.Uint32,
 		reflect.Uint64:
-		val := int64(0)
-		err := dao.aggregate(ctx, data, fieldSelect, &val)
-		return val, err
+		var val sql.NullInt64
+		sum := int64(0)
+		err := dao.aggregate(ctx, data, fieldSelect, []interface{}{&val}, func() {
+			sum += val.Int64
+		})
+		return sum, err
 	case
 		reflect.Float32,
 		reflect.Float64:
-		val := float64(0)
-		err := dao.aggregate(ctx, data, fieldSelect, &val)
-		return val, err
+		var val sql.NullFloat64
+		sum := float64(0)
+		err := dao.aggregate(ctx, data, fieldSelect, []interface{}{&val}, func() {
+			sum += val.Float64
+		})
+		return sum, err
 	default:
 		panic("Unsupport type for summing")
 	}
 }
 
 func (dao *Dao) Avg(ctx context.Context, name string, data query.Data) (val float64, err error) {
+	ctx, finish := dao.observe(ctx, "Avg")
+	defer finish(&err)
 	columnName := query.GetColumn(name, dao.fieldMap, dao.columnMap, true)
 	field := dao.columnMap[columnName]
-	err = dao.aggregate(ctx, data, "avg(`"+field.Column+"`)", &val)
+	if dao.sharding == nil {
+		var avg sql.NullFloat64
+		err = dao.aggregate(ctx, data, "avg(`"+field.Column+"`)", []interface{}{&avg}, func() {
+			val = avg.Float64
+		})
+		return
+	}
+	// average across shards
+	var (
+		sum        sql.NullFloat64
+		cnt        int64
+		sumAll     float64
+		cntAll     int64
+		aggregator = "sum(`" + field.Column + "`), count(`" + field.Column + "`)"
+	)
+	err = dao.aggregate(ctx, data, aggregator, []interface{}{&sum, &cnt}, func() {
+		sumAll += sum.Float64
+		cntAll += cnt
+	})
+	if err == nil && cntAll > 0 {
+		val = sumAll / float64(cntAll)
+	}
 	return
 }
 
-func (dao *Dao) Insert(ctx context.Context, obj interface{}, opts ...options.InsertOption) (int64, int64, error) {
+func (dao *Dao) Insert(ctx context.Context, obj interface{}, opts ...options.InsertOption) (affected, id int64, err error) {
+	ctx, finish := dao.observe(ctx, "Insert")
+	defer finish(&err)
 	affected, ids, err := dao.BatchInsert(ctx, []interface{}{obj}, opts...)
 	if len(ids) > 0 {
 		return affected, ids[0], err
@@ -310,89 +688,243 @@ func (dao *Dao) Insert(ctx context.Context, obj interface{}, opts ...options.Ins
 }
 
 func (dao *Dao) BatchInsert(ctx context.Context, arr []interface{}, opts ...options.InsertOption) (affected int64, inserted []int64, err error) {
+	ctx, finish := dao.observe(ctx, "BatchInsert")
+	defer finish(&err)
 	if len(arr) == 0 {
 		return
 	}
+	defer dao.invalidate(ctx)
 	inserted = make([]int64, len(arr))
 	cfg := &options.InsertOptions{}
 	for _, fn := range opts {
 		fn(cfg)
 	}
-	holder := "(" + dao.valuesHolder + ")"
-	sqlBase := options.InsertBaseSQL(dao.table, dao.columnsAll, cfg)
-	var txn *sql.Tx
-	if txnCtx, ok := ctx.(*DaoTxnContext); ok {
-		txn = txnCtx.Txn()
-	} else {
-		txn, err = dao.db.BeginTx(ctx, nil)
-		if err != nil {
+	if dao.hooks.beforeInsert || dao.hooks.afterInsert {
+		arr = dao.hookTargets(arr)
+	}
+	if dao.hooks.beforeInsert {
+		for _, obj := range arr {
+			if hook, ok := obj.(BeforeInserter); ok {
+				if err = hook.BeforeInsert(ctx); err != nil {
+					return
+				}
+			}
+		}
+	}
+	var tenant interface{}
+	if dao.tenant != nil {
+		if tenant, err = dao.tenantOf(ctx); err != nil {
 			return
 		}
-		defer txn.Commit()
 	}
+	holder := "(" + dao.valuesHolder + ")"
+	sqlSuffix := holder + ";"
+	sqlBases := make(map[string]string, 1)
+	txns := &shardTxns{ctx: ctx, dao: dao}
+	defer func() {
+		if txns.finish(&err) {
+			affected = 0
+			inserted = make([]int64, len(arr))
+		}
+	}()
 
-	sqlStr := sqlBase + holder + ";"
 	values := make([]interface{}, len(dao.fields))
 	for i, obj := range arr {
 		err := model.Flatten(values, dao.modelType, dao.fields, obj)
 		if err != nil {
-			logrus.Warn("Flatten object failed, ignore: ", err.Error())
+			dao.logger.Warn("Flatten object failed, ignore", err)
 			continue
 		}
-		result, err := txn.ExecContext(ctx, sqlStr, values...)
+		if dao.tenant != nil {
+			values[dao.tenantPos] = tenant
+		}
+		shard, err := dao.locate(ctx, obj)
 		if err != nil {
-			logrus.Warn("Insert into table failed: ", err.Error())
+			dao.logger.Warn("Locate shard failed, ignore", err)
 			continue
 		}
-		if result != nil {
-			insert_id, err := result.LastInsertId()
-			if err != nil {
-				logrus.Warn("Fetch insert_id failed: ", err.Error())
-			} else {
-				inserted[i] = insert_id
-			}
-			num, err := result.RowsAffected()
-			if err != nil {
-				logrus.Warn("Fetch affected failed: ", err.Error())
-			} else {
-				affected += num
+		e, err := txns.executor(shard)
+		if err != nil {
+			return affected, inserted, err
+		}
+		sqlBase, ok := sqlBases[shard.Table]
+		if !ok {
+			sqlBase = options.InsertBaseSQL(shard.Table, dao.columnsAll, cfg)
+			sqlBases[shard.Table] = sqlBase
+		}
+		result, err := dao.exec(ctx, e, types.OperationInsert, shard.Table, sqlBase+sqlSuffix, values...)
+		if err != nil {
+			// already logged as a failed statement
+			continue
+		}
+		inserted[i] = result.LastInsertId
+		affected += result.RowsAffected
+		if hook, ok := obj.(AfterInserter); ok && dao.hooks.afterInsert {
+			if err := hook.AfterInsert(ctx); err != nil {
+				return affected, inserted, err
 			}
 		}
 	}
 	return
 }
 
-func (dao *Dao) Update(ctx context.Context, item interface{}) (int64, error) {
+// Update updates all fields of the object by its primaries.
+//	If the model has a version field ErrStaleObject is returned when no row matched,
+//	and the version of object is increased on success when passed by reference.
+func (dao *Dao) Update(ctx context.Context, item interface{}) (affected int64, err error) {
+	ctx, finish := dao.observe(ctx, "Update")
+	defer finish(&err)
 	return dao.BatchUpdate(ctx, []interface{}{item})
 }
 
+// BatchUpdate updates objects in one transaction.
+//	ErrStaleObject is returned if any of them is stale when the model has a version field.
+//	Updating stops at the first failure, and nothing is written if the transaction is opened by dao.
 func (dao *Dao) BatchUpdate(ctx context.Context, items []interface{}) (affected int64, err error) {
-	var txn *sql.Tx
-	if txnCtx, ok := ctx.(*DaoTxnContext); ok {
-		txn = txnCtx.Txn()
-	} else {
-		txn, err = dao.db.BeginTx(ctx, nil)
-		if err != nil {
+	ctx, finish := dao.observe(ctx, "BatchUpdate")
+	defer finish(&err)
+	return dao.batchUpdate(ctx, items, dao.fields)
+}
+
+// UpdateFields updates only specified fields of the object by its primaries.
+//	Fields can be specified by field names or column names, and unknown ones are reported as an error.
+//	Version checking works as Update if the model has a version field.
+func (dao *Dao) UpdateFields(ctx context.Context, item interface{}, names ...string) (affected int64, err error) {
+	ctx, finish := dao.observe(ctx, "UpdateFields")
+	defer finish(&err)
+	selected := make(map[*types.ModelField]bool, len(names))
+	for _, name := range names {
+		column := query.GetColumn(name, dao.fieldMap, dao.columnMap, false)
+		if column == "" {
+			return 0, errors.New("Unknown field to update: " + name)
+		}
+		selected[dao.columnMap[column]] = true
+	}
+	fields := dao.partialFields(selected)
+	if fields == nil {
+		return 0, errors.New("No fields to update")
+	}
+	return dao.batchUpdate(ctx, []interface{}{item}, fields)
+}
+
+// UpdateChanged compares the modified object with its original snapshot
+//	and updates only the fields changed.
+//	Nothing will be performed if there is no difference.
+func (dao *Dao) UpdateChanged(ctx context.Context, original, modified interface{}) (affected int64, err error) {
+	ctx, finish := dao.observe(ctx, "UpdateChanged")
+	defer finish(&err)
+	valuesOriginal := make([]interface{}, len(dao.fields))
+	valuesModified := make([]interface{}, len(dao.fields))
+	if err := model.Flatten(valuesOriginal, dao.modelType, dao.fields, original); err != nil {
+		return 0, err
+	}
+	if err := model.Flatten(valuesModified, dao.modelType, dao.fields, modified); err != nil {
+		return 0, err
+	}
+	selected := make(map[*types.ModelField]bool)
+	for i, f := range dao.fields {
+		if reflect.DeepEqual(valuesOriginal[i], valuesModified[i]) {
+			continue
+		}
+		if f.Primary {
+			return 0, errors.New("Primary keys of the objects are different")
+		}
+		selected[f] = true
+	}
+	fields := dao.partialFields(selected)
+	if fields == nil {
+		return 0, nil
+	}
+	return dao.batchUpdate(ctx, []interface{}{modified}, fields)
+}
+
+// partialFields returns fields in original order including primaries, version, tenant and selected ones.
+//	Nil is returned if there is no selected field to update.
+func (dao *Dao) partialFields(selected map[*types.ModelField]bool) []*types.ModelField {
+	fields := make([]*types.ModelField, 0, len(dao.fields))
+	cnt := 0
+	for _, f := range dao.fields {
+		switch {
+		case f.Primary, f.Version, f.Tenant:
+			fields = append(fields, f)
+		case selected[f]:
+			fields = append(fields, f)
+			cnt++
+		}
+	}
+	if cnt == 0 {
+		return nil
+	}
+	return fields
+}
+
+func (dao *Dao) batchUpdate(ctx context.Context, items []interface{}, fields []*types.ModelField) (affected int64, err error) {
+	defer dao.invalidate(ctx)
+	if dao.hooks.beforeUpdate || dao.hooks.afterUpdate {
+		items = dao.hookTargets(items)
+	}
+	if dao.hooks.beforeUpdate {
+		for _, item := range items {
+			if hook, ok := item.(BeforeUpdater); ok {
+				if err = hook.BeforeUpdate(ctx); err != nil {
+					return
+				}
+			}
+		}
+	}
+	var tenant interface{}
+	if dao.tenant != nil {
+		if tenant, err = dao.tenantOf(ctx); err != nil {
 			return
 		}
-		defer txn.Commit()
 	}
-	sqlStr := options.UpdateSQL(dao.table, dao.fields)
+	var updated []interface{}
+	txns := &shardTxns{ctx: ctx, dao: dao}
+	defer func() {
+		if txns.finish(&err) {
+			affected = 0
+			for _, item := range updated {
+				dao.increaseVersion(item, -1)
+			}
+		}
+	}()
+	sqlStrs := make(map[string]string, 1)
+	// rows excluded by scopes are not updated
+	scopeSQL, scopeArgs := dao.scopeSQL()
 
-	values := make([]interface{}, len(dao.fields))
-	valuesPrimary := make([]interface{}, len(dao.primaries))
-	args := make([]interface{}, len(dao.fields))
+	values := make([]interface{}, len(fields))
+	valuesPrimary := make([]interface{}, 0, len(dao.primaries)+1)
+	args := make([]interface{}, len(fields)+len(scopeArgs))
 	for _, item := range items {
-		err = model.Flatten(values, dao.modelType, dao.fields, item)
+		if err = model.Flatten(values, dao.modelType, fields, item); err != nil {
+			return
+		}
+		shard, err := dao.locate(ctx, item)
 		if err != nil {
-			logrus.Warn("Flatten object failed, ignore: ", err.Error())
-			continue
+			return affected, err
+		}
+		e, err := txns.executor(shard)
+		if err != nil {
+			return affected, err
+		}
+		sqlStr, ok := sqlStrs[shard.Table]
+		if !ok {
+			sqlStr = options.UpdateSQL(shard.Table, fields)
+			if scopeSQL != "" {
+				sqlStr += " and " + scopeSQL
+			}
+			sqlStrs[shard.Table] = sqlStr
 		}
 		valuesPrimary = valuesPrimary[:0]
 		pos := 0
+		var version interface{}
 		for i, v := range values {
-			if dao.fields[i].Primary {
+			if fields[i].Primary {
 				valuesPrimary = append(valuesPrimary, v)
+			} else if fields[i].Tenant {
+				valuesPrimary = append(valuesPrimary, tenant)
+			} else if fields[i].Version {
+				version = v
 			} else {
 				args[pos] = v
 				pos++
@@ -402,55 +934,98 @@ func (dao *Dao) BatchUpdate(ctx context.Context, items []interface{}) (affected
 			args[pos] = v
 			pos++
 		}
-		result, err := txn.Exec(sqlStr, args...)
-		if err != nil {
-			logrus.Warn("Update table failed: ", err.Error())
-			continue
+		if dao.version != nil {
+			args[pos] = version
+			pos++
 		}
-		num, err := result.RowsAffected()
+		pos += copy(args[pos:], scopeArgs)
+		result, err := dao.exec(ctx, e, types.OperationUpdate, shard.Table, sqlStr, args[:pos]...)
 		if err != nil {
-			logrus.Warn("Fetch affected failed: ", err.Error())
-		} else {
-			affected += num
+			return affected, err
+		}
+		affected += result.RowsAffected
+		if dao.version != nil {
+			if result.RowsAffected == 0 {
+				return affected, ErrStaleObject
+			}
+			dao.increaseVersion(item, 1)
+			updated = append(updated, item)
+		}
+		if hook, ok := item.(AfterUpdater); ok && dao.hooks.afterUpdate {
+			if err := hook.AfterUpdate(ctx); err != nil {
+				return affected, err
+			}
 		}
 	}
 	return
 }
 
+// increaseVersion increases the version field of object by delta if it's passed by reference.
+func (dao *Dao) increaseVersion(item interface{}, delta int64) {
+	ptr := model.RealPointer(item)
+	if ptr == nil {
+		return
+	}
+	val := reflect.ValueOf(ptr).Elem().Field(dao.version.Index)
+	switch val.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		val.SetUint(val.Uint() + uint64(delta))
+	default:
+		val.SetInt(val.Int() + delta)
+	}
+}
+
+// UpdateBy updates rows matched by the condition.
+//	For a sharding dao it's performed on every shard matched.
 func (dao *Dao) UpdateBy(ctx context.Context, data query.Data, entries ...*types.UpdateEntry) (affected int64, err error) {
+	ctx, finish := dao.observe(ctx, "UpdateBy")
+	defer finish(&err)
 	conditionSQL, args := query.ConditionSQL(dao.fieldMap, dao.columnMap, &data)
 	if conditionSQL == "" {
 		return 0, errors.New("Whole table updating is not allowed")
 	}
-
-	sqlBuilder := strings.Builder{}
-	sqlBuilder.WriteString("update `")
-	sqlBuilder.WriteString(dao.table)
-	sqlBuilder.WriteString("` set ")
+	if dao.scoped() {
+		if data, err = dao.applyScopes(ctx, data); err != nil {
+			return
+		}
+		conditionSQL, args = query.ConditionSQL(dao.fieldMap, dao.columnMap, &data)
+	}
 	updateSQL, values := options.UpdateEntrySQL(entries, dao.fieldMap, dao.columnMap)
 	if updateSQL == "" {
 		return 0, errors.New("Invalid updating")
 	}
+	defer dao.invalidate(ctx)
 	values = append(values, args...)
-	sqlBuilder.WriteString(updateSQL)
-	sqlBuilder.WriteString(" ")
-	sqlBuilder.WriteString(conditionSQL)
-
-	var result sql.Result
-	if txnCtx, ok := ctx.(*DaoTxnContext); ok {
-		result, err = txnCtx.Txn().Exec(sqlBuilder.String(), values...)
-	} else {
-		result, err = dao.db.Exec(sqlBuilder.String(), values...)
-	}
+	shards, err := dao.route(ctx, &data)
 	if err != nil {
 		return 0, err
 	}
 
-	affected, err = result.RowsAffected()
+	for _, shard := range shards {
+		e, err := dao.shardExecutor(ctx, shard)
+		if err != nil {
+			return affected, err
+		}
+		sqlBuilder := strings.Builder{}
+		sqlBuilder.WriteString("update `")
+		sqlBuilder.WriteString(shard.Table)
+		sqlBuilder.WriteString("` set ")
+		sqlBuilder.WriteString(updateSQL)
+		sqlBuilder.WriteString(" ")
+		sqlBuilder.WriteString(conditionSQL)
+
+		result, err := dao.exec(ctx, e, types.OperationUpdate, shard.Table, sqlBuilder.String(), values...)
+		if err != nil {
+			return affected, err
+		}
+		affected += result.RowsAffected
+	}
 	return
 }
 
-func (dao *Dao) Delete(ctx context.Context, ids ...interface{}) (int64, error) {
+func (dao *Dao) Delete(ctx context.Context, ids ...interface{}) (affected int64, err error) {
+	ctx, finish := dao.observe(ctx, "Delete")
+	defer finish(&err)
 	if len(ids) < 1 {
 		return 0, nil
 	}
@@ -465,27 +1040,49 @@ func (dao *Dao) Delete(ctx context.Context, ids ...interface{}) (int64, error) {
 }
 
 func (dao *Dao) DeleteRange(ctx context.Context, data query.Data) (affected int64, err error) {
+	ctx, finish := dao.observe(ctx, "DeleteRange")
+	defer finish(&err)
 	conditionSQL, args := query.ConditionSQL(dao.fieldMap, dao.columnMap, &data)
 	if conditionSQL == "" {
 		logrus.Panic("Deletion without condition is not allowed")
 	}
+	defer dao.invalidate(ctx)
+	if dao.scoped() {
+		if data, err = dao.applyScopes(ctx, data); err != nil {
+			return
+		}
+		conditionSQL, args = query.ConditionSQL(dao.fieldMap, dao.columnMap, &data)
+	}
 
-	sqlBuilder := strings.Builder{}
-	sqlBuilder.WriteString("delete from `")
-	sqlBuilder.WriteString(dao.table)
-	sqlBuilder.WriteString("` ")
-	sqlBuilder.WriteString(conditionSQL)
-
-	var result sql.Result
-	if txnCtx, ok := ctx.(*DaoTxnContext); ok {
-		result, err = txnCtx.Txn().Exec(sqlBuilder.String(), args...)
-	} else {
-		result, err = dao.db.Exec(sqlBuilder.String(), args...)
+	if dao.hooks.beforeDelete {
+		if err = dao.zeroModel().(BeforeDeleter).BeforeDelete(ctx, data); err != nil {
+			return
+		}
 	}
+
+	shards, err := dao.route(ctx, &data)
 	if err != nil {
 		return
 	}
+	for _, shard := range shards {
+		e, err := dao.shardExecutor(ctx, shard)
+		if err != nil {
+			return affected, err
+		}
+		sqlBuilder := strings.Builder{}
+		sqlBuilder.WriteString("delete from `")
+		sqlBuilder.WriteString(shard.Table)
+		sqlBuilder.WriteString("` ")
+		sqlBuilder.WriteString(conditionSQL)
 
-	affected, err = result.RowsAffected()
+		result, err := dao.exec(ctx, e, types.OperationDelete, shard.Table, sqlBuilder.String(), args...)
+		if err != nil {
+			return affected, err
+		}
+		affected += result.RowsAffected
+	}
+	if dao.hooks.afterDelete {
+		err = dao.zeroModel().(AfterDeleter).AfterDelete(ctx, data, affected)
+	}
 	return
 }
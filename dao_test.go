@@ -10,28 +10,27 @@ import (
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jasonjoo2010/godao/options"
+	"github.com/jasonjoo2010/godao/query"
 	"github.com/jasonjoo2010/godao/types"
 	"github.com/stretchr/testify/assert"
 )
 
-// Demo table structure:
-// CREATE TABLE `demo` (
-//   `id` bigint(20) NOT NULL AUTO_INCREMENT,
-//   `name` varchar(100) NOT NULL DEFAULT '',
-//   `value` varchar(255) NOT NULL DEFAULT '',
-//   `cnt` int(11) NOT NULL DEFAULT '0',
-//   `created` bigint(20) NOT NULL DEFAULT '0',
-//   PRIMARY KEY (`id`),
-//   KEY `name` (`name`)
-// ) ENGINE=InnoDB
-
+// Demo table can be created by statement from CreateTableSQL()
 type Demo struct {
-	Id          int64 `dao:"primary;auto_increment"`
-	Name, Value string
-	Cnt         int
-	Created     int64
+	Id      int64  `dao:"primary;auto_increment"`
+	Name    string `dao:"size=100;default=;index=name"`
+	Value   string `dao:"default="`
+	Cnt     int    `dao:"default=0"`
+	Created int64  `dao:"default=0"`
+}
+
+type VersionedDemo struct {
+	Id      int64 `dao:"primary;auto_increment"`
+	Name    string
+	Version int64 `dao:"version"`
 }
 
 func testDB() *sql.DB {
@@ -39,6 +38,15 @@ func testDB() *sql.DB {
 	return db
 }
 
+// mockDB creates a db whose statements are matched literally.
+func mockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
 func TestDaoBasic(t *testing.T) {
 	db := testDB()
 	defer db.Close()
@@ -260,3 +268,181 @@ func TestTxnCommit(t *testing.T) {
 
 	dao.Delete(context.Background(), ids[:]...)
 }
+
+func TestOptimisticLocking(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(VersionedDemo{}, db)
+	sqlStr := "update `versioned_demo` set `name` = ?, `version` = `version` + 1 where `id` = ? and `version` = ?"
+
+	demo := &VersionedDemo{Id: 1, Name: "n1", Version: 3}
+
+	// matched
+	mock.ExpectBegin()
+	mock.ExpectExec(sqlStr).WithArgs("n1", int64(1), int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	affected, err := dao.Update(context.Background(), demo)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), affected)
+	assert.Equal(t, int64(4), demo.Version)
+
+	// stale
+	mock.ExpectBegin()
+	mock.ExpectExec(sqlStr).WithArgs("n1", int64(1), int64(4)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	affected, err = dao.Update(context.Background(), demo)
+	assert.Equal(t, ErrStaleObject, err)
+	assert.Equal(t, int64(0), affected)
+	assert.Equal(t, int64(4), demo.Version)
+
+	// passed by value
+	mock.ExpectBegin()
+	mock.ExpectExec(sqlStr).WithArgs("n1", int64(1), int64(4)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	affected, err = dao.Update(context.Background(), *demo)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	// batch is rolled back if any is stale
+	other := &VersionedDemo{Id: 2, Name: "n2", Version: 7}
+	mock.ExpectBegin()
+	mock.ExpectExec(sqlStr).WithArgs("n1", int64(1), int64(4)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(sqlStr).WithArgs("n2", int64(2), int64(7)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	affected, err = dao.BatchUpdate(context.Background(), []interface{}{demo, other})
+	assert.Equal(t, ErrStaleObject, err)
+	assert.Equal(t, int64(0), affected)
+	assert.Equal(t, int64(4), demo.Version)
+	assert.Equal(t, int64(7), other.Version)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdatePartial(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(Demo{}, db)
+
+	demo := &Demo{Id: 1, Name: "n1", Value: "v1", Cnt: 2, Created: 3}
+
+	// specified fields
+	mock.ExpectBegin()
+	mock.ExpectExec("update `demo` set `value` = ?, `cnt` = ? where `id` = ?").
+		WithArgs("v1", 2, int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	affected, err := dao.UpdateFields(context.Background(), demo, "Cnt", "value")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	_, err = dao.UpdateFields(context.Background(), demo, "Id")
+	assert.NotNil(t, err)
+	_, err = dao.UpdateFields(context.Background(), demo, "Cnt", "NotExisted")
+	assert.NotNil(t, err)
+
+	// changed fields
+	modified := *demo
+	modified.Name = "n2"
+	mock.ExpectBegin()
+	mock.ExpectExec("update `demo` set `name` = ? where `id` = ?").
+		WithArgs("n2", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	affected, err = dao.UpdateChanged(context.Background(), demo, &modified)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	// nothing changed
+	affected, err = dao.UpdateChanged(context.Background(), demo, *demo)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), affected)
+
+	// primary changed
+	modified.Id = 2
+	_, err = dao.UpdateChanged(context.Background(), demo, &modified)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestReadWriteSplitting(t *testing.T) {
+	primary, mockPrimary := mockDB(t)
+	defer primary.Close()
+	replica, mockReplica := mockDB(t)
+	defer replica.Close()
+	dao := NewDao(Demo{}, primary, options.WithReplicas(replica))
+	countSQL := "select count(*) from `demo`"
+
+	// reading from replica
+	mockReplica.ExpectQuery(countSQL).WillReturnRows(sqlmock.NewRows([]string{"cnt"}).AddRow(1))
+	cnt, err := dao.Count(context.Background(), query.Data{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	// forced
+	mockPrimary.ExpectQuery(countSQL).WillReturnRows(sqlmock.NewRows([]string{"cnt"}).AddRow(2))
+	cnt, err = dao.Count(ForcePrimary(context.Background()), query.Data{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), cnt)
+
+	mockPrimary.ExpectQuery("select `id` as `Id` from `demo` where `id` = ? limit 0, 1;").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1))
+	obj, err := dao.SelectOne(context.Background(), 1, options.WithFields("Id"), options.WithPrimary())
+	assert.Nil(t, err)
+	assert.NotNil(t, obj)
+
+	// writing and transaction to primary
+	mockPrimary.ExpectExec("delete from `demo` where `id` = ?").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockPrimary.ExpectBegin()
+	mockPrimary.ExpectQuery(countSQL).WillReturnRows(sqlmock.NewRows([]string{"cnt"}).AddRow(0))
+	mockPrimary.ExpectCommit()
+	_, err = dao.Delete(context.Background(), 1)
+	assert.Nil(t, err)
+	ctx, err := dao.Txn(nil)
+	assert.Nil(t, err)
+	cnt, err = dao.Count(ctx, query.Data{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), cnt)
+	ctx.Txn().Commit()
+
+	assert.Nil(t, mockPrimary.ExpectationsWereMet())
+	assert.Nil(t, mockReplica.ExpectationsWereMet())
+}
+
+func TestDynamicTable(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(Demo{}, db, options.WithTable("log"))
+	monthly := dao.WithTable("log_202610")
+
+	mock.ExpectQuery("select count(*) from `log_202610`").WillReturnRows(sqlmock.NewRows([]string{"cnt"}).AddRow(1))
+	mock.ExpectQuery("select count(*) from `log`").WillReturnRows(sqlmock.NewRows([]string{"cnt"}).AddRow(2))
+	mock.ExpectBegin()
+	mock.ExpectExec("delete from `log_202609` where `id` = ?").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	cnt, err := monthly.Count(context.Background(), query.Data{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+	cnt, err = dao.Count(context.Background(), query.Data{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), cnt)
+
+	// overridden in transaction
+	txnCtx, err := dao.Txn(nil)
+	assert.Nil(t, err)
+	ctx := OverrideTable(txnCtx, "log_202609")
+	_, ok := ctx.(*DaoTxnContext)
+	assert.True(t, ok)
+	affected, err := dao.Delete(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), affected)
+	txnCtx.Txn().Commit()
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jasonjoo2010/godao/options"
+	"github.com/jasonjoo2010/godao/sharding"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExists(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(Demo{}, db)
+	ctx := context.Background()
+
+	mock.ExpectQuery("select 1 from `demo` where `name` = ? limit 0, 1").
+		WithArgs("a").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	exists, err := dao.ExistsBy(ctx, "Name", "a")
+	assert.Nil(t, err)
+	assert.True(t, exists)
+
+	// order and offset are dropped
+	mock.ExpectQuery("select 1 from `demo` where `id` in (?, ?) or `cnt` > ? limit 0, 1").
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}))
+	exists, err = dao.Exists(ctx, (&Query{}).
+		In("Id", []interface{}{1, 2}).
+		Greater("Cnt", 3).
+		Or().
+		OrderBy("Id", true).
+		Page(3, 10).
+		Data())
+	assert.Nil(t, err)
+	assert.False(t, exists)
+
+	// in transaction
+	mock.ExpectBegin()
+	mock.ExpectQuery("select 1 from `demo` where `id` = ? limit 0, 1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectCommit()
+	txn, err := dao.Txn(nil)
+	assert.Nil(t, err)
+	exists, err = dao.ExistsBy(txn, "Id", 1)
+	assert.Nil(t, err)
+	assert.True(t, exists)
+	assert.Nil(t, txn.Txn().Commit())
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestExistsSharding(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(ShardedOrder{}, db, options.WithSharding(sharding.NewModulo("UserId", "order_%d", 2)))
+
+	mock.ExpectQuery("select 1 from `order_0` where `amount` > ? limit 0, 1").
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}))
+	mock.ExpectQuery("select 1 from `order_1` where `amount` > ? limit 0, 1").
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	exists, err := dao.Exists(context.Background(), (&Query{}).Greater("Amount", 10).Data())
+	assert.Nil(t, err)
+	assert.True(t, exists)
+
+	// the rest shards are skipped once found
+	mock.ExpectQuery("select 1 from `order_0` where `amount` > ? limit 0, 1").
+		WithArgs(20).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	exists, err = dao.Exists(context.Background(), (&Query{}).Greater("Amount", 20).Data())
+	assert.Nil(t, err)
+	assert.True(t, exists)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
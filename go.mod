@@ -3,7 +3,8 @@ module github.com/jasonjoo2010/godao
 go 1.14
 
 require (
-	github.com/go-sql-driver/mysql v1.5.0 // test
+	github.com/DATA-DOG/go-sqlmock v1.5.0 // test
+	github.com/go-sql-driver/mysql v1.5.0
 	github.com/jasonjoo2010/enhanced-utils v0.0.0-20200603160505-ca106040678a
 	github.com/sirupsen/logrus v1.6.0
 	github.com/stretchr/testify v1.5.1 // test
@@ -0,0 +1,105 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/jasonjoo2010/godao/model"
+	"github.com/jasonjoo2010/godao/query"
+)
+
+// BeforeInserter is invoked before inserting the object.
+//	Insertion will be aborted if an error is returned.
+type BeforeInserter interface {
+	BeforeInsert(ctx context.Context) error
+}
+
+// AfterInserter is invoked after the object is inserted successfully.
+//	If an error is returned, the transaction opened by dao is rolled back
+//	while the one in context is left to the caller.
+type AfterInserter interface {
+	AfterInsert(ctx context.Context) error
+}
+
+// BeforeUpdater is invoked before updating the object.
+//	Updating will be aborted if an error is returned.
+type BeforeUpdater interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+// AfterUpdater is invoked after the object is updated successfully.
+//	If an error is returned, the transaction opened by dao is rolled back
+//	while the one in context is left to the caller.
+type AfterUpdater interface {
+	AfterUpdate(ctx context.Context) error
+}
+
+// AfterSelecter is invoked after the object is fetched from table.
+type AfterSelecter interface {
+	AfterSelect(ctx context.Context) error
+}
+
+// BeforeDeleter is invoked on a zero object of model before deleting.
+//	Deletion will be aborted if an error is returned.
+type BeforeDeleter interface {
+	BeforeDelete(ctx context.Context, data query.Data) error
+}
+
+// AfterDeleter is invoked on a zero object of model after deleting.
+//	Deletion is not in a transaction opened by dao and can't be aborted by the error returned.
+type AfterDeleter interface {
+	AfterDelete(ctx context.Context, data query.Data, affected int64) error
+}
+
+type modelHooks struct {
+	beforeInsert, afterInsert bool
+	beforeUpdate, afterUpdate bool
+	afterSelect               bool
+	beforeDelete, afterDelete bool
+}
+
+func parseHooks(typ reflect.Type) modelHooks {
+	ptr := reflect.PtrTo(typ)
+	return modelHooks{
+		beforeInsert: ptr.Implements(reflect.TypeOf((*BeforeInserter)(nil)).Elem()),
+		afterInsert:  ptr.Implements(reflect.TypeOf((*AfterInserter)(nil)).Elem()),
+		beforeUpdate: ptr.Implements(reflect.TypeOf((*BeforeUpdater)(nil)).Elem()),
+		afterUpdate:  ptr.Implements(reflect.TypeOf((*AfterUpdater)(nil)).Elem()),
+		afterSelect:  ptr.Implements(reflect.TypeOf((*AfterSelecter)(nil)).Elem()),
+		beforeDelete: ptr.Implements(reflect.TypeOf((*BeforeDeleter)(nil)).Elem()),
+		afterDelete:  ptr.Implements(reflect.TypeOf((*AfterDeleter)(nil)).Elem()),
+	}
+}
+
+// hookTarget returns the single layer pointer of object through which hooks can be invoked.
+//	A copy is made if object is passed by value.
+func (dao *Dao) hookTarget(obj interface{}) interface{} {
+	if ptr := model.RealPointer(obj); ptr != nil {
+		return ptr
+	}
+	if reflect.TypeOf(obj) != dao.modelType {
+		// leave it to be reported in flattening
+		return obj
+	}
+	val := reflect.New(dao.modelType)
+	val.Elem().Set(reflect.ValueOf(obj))
+	return val.Interface()
+}
+
+// hookTargets prepares objects for hooks without touching the original slice.
+func (dao *Dao) hookTargets(arr []interface{}) []interface{} {
+	targets := make([]interface{}, len(arr))
+	for i, obj := range arr {
+		targets[i] = dao.hookTarget(obj)
+	}
+	return targets
+}
+
+// zeroModel returns a pointer of zero model object.
+func (dao *Dao) zeroModel() interface{} {
+	return reflect.New(dao.modelType).Interface()
+}
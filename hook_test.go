@@ -0,0 +1,138 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jasonjoo2010/godao/query"
+	"github.com/stretchr/testify/assert"
+)
+
+type HookedDemo struct {
+	Id      int64 `dao:"primary;auto_increment"`
+	Name    string
+	Created int64
+}
+
+var (
+	errHookAbort = errors.New("abort")
+	hookDeleted  int64
+)
+
+func (d *HookedDemo) BeforeInsert(ctx context.Context) error {
+	if d.Name == "" {
+		return errHookAbort
+	}
+	if d.Created == 0 {
+		d.Created = 100
+	}
+	return nil
+}
+
+func (d *HookedDemo) BeforeUpdate(ctx context.Context) error {
+	if d.Name == "" {
+		return errHookAbort
+	}
+	return nil
+}
+
+func (d *HookedDemo) AfterSelect(ctx context.Context) error {
+	d.Name = "selected-" + d.Name
+	return nil
+}
+
+func (d *HookedDemo) AfterDelete(ctx context.Context, data query.Data, affected int64) error {
+	hookDeleted += affected
+	return nil
+}
+
+func TestHooks(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(HookedDemo{}, db)
+
+	// defaulting
+	mock.ExpectBegin()
+	mock.ExpectExec("insert into `hooked_demo` (`id`, `name`, `created`) values (?, ?, ?);").
+		WithArgs(int64(0), "n1", int64(100)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	affected, id, err := dao.Insert(context.Background(), HookedDemo{Name: "n1"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), affected)
+	assert.Equal(t, int64(1), id)
+
+	// validation
+	_, _, err = dao.Insert(context.Background(), &HookedDemo{})
+	assert.Equal(t, errHookAbort, err)
+	_, err = dao.Update(context.Background(), &HookedDemo{Id: 1})
+	assert.Equal(t, errHookAbort, err)
+
+	// derived field
+	mock.ExpectQuery("select `id` as `Id`, `name` as `Name`, `created` as `Created` from `hooked_demo` where `id` = ? limit 0, 1;").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"Id", "Name", "Created"}).AddRow(1, "n1", 100))
+	obj, err := dao.SelectOne(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "selected-n1", obj.(*HookedDemo).Name)
+
+	// after deleting
+	mock.ExpectExec("delete from `hooked_demo` where `id` = ?").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	affected, err = dao.Delete(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), affected)
+	assert.Equal(t, int64(1), hookDeleted)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+type AuditedDemo struct {
+	Id   int64 `dao:"primary;auto_increment"`
+	Name string
+}
+
+func (d *AuditedDemo) AfterInsert(ctx context.Context) error {
+	if d.Name == "fail" {
+		return errHookAbort
+	}
+	return nil
+}
+
+func (d *AuditedDemo) AfterUpdate(ctx context.Context) error {
+	return d.AfterInsert(ctx)
+}
+
+func TestAfterHooksRollback(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(AuditedDemo{}, db)
+	insertSQL := "insert into `audited_demo` (`id`, `name`) values (?, ?);"
+
+	mock.ExpectBegin()
+	mock.ExpectExec(insertSQL).WithArgs(int64(0), "ok").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(insertSQL).WithArgs(int64(0), "fail").WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectRollback()
+	affected, ids, err := dao.BatchInsert(context.Background(), []interface{}{&AuditedDemo{Name: "ok"}, &AuditedDemo{Name: "fail"}})
+	assert.Equal(t, errHookAbort, err)
+	assert.Equal(t, int64(0), affected)
+	assert.Equal(t, []int64{0, 0}, ids)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("update `audited_demo` set `name` = ? where `id` = ?").
+		WithArgs("fail", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+	affected, err = dao.Update(context.Background(), &AuditedDemo{Id: 1, Name: "fail"})
+	assert.Equal(t, errHookAbort, err)
+	assert.Equal(t, int64(0), affected)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,158 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/jasonjoo2010/godao/model"
+	"github.com/jasonjoo2010/godao/types"
+)
+
+// StructDef is a model struct found in source
+type StructDef struct {
+	Name   string
+	Fields []*types.ModelField
+}
+
+// ParseStructs parses model structs from go source files of the same package.
+//	Fields are parsed by the same rules as model.Parse. If names is empty,
+//	structs having a primary field are treated as models.
+func ParseStructs(files []string, names ...string) (string, []StructDef, error) {
+	fset := token.NewFileSet()
+	pkg := ""
+	found := make(map[string]StructDef)
+	var order []string
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			return "", nil, err
+		}
+		if pkg == "" {
+			pkg = f.Name.Name
+		} else if pkg != f.Name.Name {
+			return "", nil, fmt.Errorf("Files belong to different packages: %s and %s", pkg, f.Name.Name)
+		}
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				def, err := parseStruct(ts.Name.Name, st)
+				if err != nil {
+					return "", nil, err
+				}
+				found[def.Name] = def
+				order = append(order, def.Name)
+			}
+		}
+	}
+	var defs []StructDef
+	if len(names) == 0 {
+		for _, name := range order {
+			def := found[name]
+			for _, f := range def.Fields {
+				if f.Primary {
+					defs = append(defs, def)
+					break
+				}
+			}
+		}
+		return pkg, defs, nil
+	}
+	for _, name := range names {
+		def, ok := found[name]
+		if !ok {
+			return "", nil, fmt.Errorf("Struct %s not found", name)
+		}
+		defs = append(defs, def)
+	}
+	return pkg, defs, nil
+}
+
+// parseStruct parses fields of struct through model.ParseTag
+func parseStruct(name string, st *ast.StructType) (def StructDef, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("Parse struct %s failed: %v", name, r)
+		}
+	}()
+	def.Name = name
+	for _, f := range st.Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			s, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return def, err
+			}
+			tag = reflect.StructTag(s)
+		}
+		names := f.Names
+		if len(names) == 0 {
+			// embedded field is named by its type
+			if ident := embeddedName(f.Type); ident != nil {
+				names = []*ast.Ident{ident}
+			}
+		}
+		for _, ident := range names {
+			if field := model.ParseTag(ident.Name, tag); field != nil {
+				def.Fields = append(def.Fields, field)
+			}
+		}
+	}
+	return def, nil
+}
+
+func embeddedName(expr ast.Expr) *ast.Ident {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel
+	}
+	return nil
+}
+
+// GenerateColumns generates typed column descriptors named `<Struct>Cols` for structs
+func GenerateColumns(pkg string, structs []StructDef) ([]byte, error) {
+	buf := bytes.Buffer{}
+	buf.WriteString("// Code generated by godao-cols. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	buf.WriteString("import \"github.com/jasonjoo2010/godao/query\"\n\n")
+	sorted := append([]StructDef(nil), structs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	for _, s := range sorted {
+		fmt.Fprintf(&buf, "// %sCols holds the typed columns of %s\n", s.Name, s.Name)
+		fmt.Fprintf(&buf, "var %sCols = struct {\n", s.Name)
+		for _, f := range s.Fields {
+			fmt.Fprintf(&buf, "\t// %s refers to column `%s`\n", f.Name, f.Column)
+			fmt.Fprintf(&buf, "\t%s query.Column\n", f.Name)
+		}
+		buf.WriteString("}{\n")
+		for _, f := range s.Fields {
+			fmt.Fprintf(&buf, "\t%s: %q,\n", f.Name, f.Name)
+		}
+		buf.WriteString("}\n\n")
+	}
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package codegen
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const modelSource = `package model
+
+type Base struct {
+	Created int64
+}
+
+type Demo struct {
+	Base
+	Id     int64  ` + "`dao:\"primary;auto_increment\"`" + `
+	Name   string ` + "`dao:\"column=nick_name\"`" + `
+	Cache  string ` + "`dao:\"omit\"`" + `
+	A, B   int
+}
+
+type Other struct {
+	Key string ` + "`dao:\"primary\"`" + `
+}
+`
+
+func TestGenerateColumns(t *testing.T) {
+	dir, err := ioutil.TempDir("", "godao-cols")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "model.go")
+	assert.Nil(t, ioutil.WriteFile(file, []byte(modelSource), 0644))
+
+	pkg, defs, err := ParseStructs([]string{file})
+	assert.Nil(t, err)
+	assert.Equal(t, "model", pkg)
+	assert.Equal(t, 2, len(defs))
+	assert.Equal(t, "Demo", defs[0].Name)
+	assert.Equal(t, "Other", defs[1].Name)
+
+	_, _, err = ParseStructs([]string{file}, "Missing")
+	assert.NotNil(t, err)
+
+	_, defs, err = ParseStructs([]string{file}, "Demo")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(defs))
+	names := make([]string, 0)
+	for _, f := range defs[0].Fields {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"Base", "Id", "Name", "A", "B"}, names)
+	assert.Equal(t, "nick_name", defs[0].Fields[2].Column)
+
+	src, err := GenerateColumns(pkg, defs)
+	assert.Nil(t, err)
+	assert.Equal(t, "// Code generated by godao-cols. DO NOT EDIT.\n"+
+		"\n"+
+		"package model\n"+
+		"\n"+
+		"import \"github.com/jasonjoo2010/godao/query\"\n"+
+		"\n"+
+		"// DemoCols holds the typed columns of Demo\n"+
+		"var DemoCols = struct {\n"+
+		"\t// Base refers to column `base`\n"+
+		"\tBase query.Column\n"+
+		"\t// Id refers to column `id`\n"+
+		"\tId query.Column\n"+
+		"\t// Name refers to column `nick_name`\n"+
+		"\tName query.Column\n"+
+		"\t// A refers to column `a`\n"+
+		"\tA query.Column\n"+
+		"\t// B refers to column `b`\n"+
+		"\tB query.Column\n"+
+		"}{\n"+
+		"\tBase: \"Base\",\n"+
+		"\tId:   \"Id\",\n"+
+		"\tName: \"Name\",\n"+
+		"\tA:    \"A\",\n"+
+		"\tB:    \"B\",\n"+
+		"}\n", string(src))
+}
@@ -0,0 +1,229 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jasonjoo2010/enhanced-utils/strutils"
+	"github.com/jasonjoo2010/godao/schema"
+)
+
+// ModelOptions controls how models are generated
+type ModelOptions struct {
+	Package string
+	// Pointers represents nullable columns as pointers instead of sql.Null* types
+	Pointers bool
+	// Dao generates typed dao constructors
+	Dao bool
+}
+
+// GoName converts a column or table name into an exported Go name
+//	which is converted back by strutils.ToUnderscore if possible, e.g. user_id => UserId
+func GoName(name string) string {
+	b := strings.Builder{}
+	upper := true
+	for _, ch := range name {
+		switch {
+		case ch == '_' || ch == '-' || ch == ' ':
+			upper = true
+			continue
+		case !unicode.IsLetter(ch) && !unicode.IsDigit(ch):
+			continue
+		case b.Len() == 0 && unicode.IsDigit(ch):
+			b.WriteByte('F')
+		}
+		if upper {
+			ch = unicode.ToUpper(ch)
+			upper = false
+		}
+		b.WriteRune(ch)
+	}
+	if b.Len() == 0 {
+		return "F"
+	}
+	return b.String()
+}
+
+// goType returns the Go type of column and packages to import
+func goType(c schema.Column, pointers bool) (string, string) {
+	typ := strings.ToLower(c.Type)
+	unsigned := strings.Contains(typ, "unsigned")
+	base := typ
+	if i := strings.IndexAny(base, "( "); i > 0 {
+		base = base[:i]
+	}
+	var t, null string
+	switch base {
+	case "tinyint":
+		switch {
+		case strings.HasPrefix(typ, "tinyint(1)"):
+			t, null = "bool", "sql.NullBool"
+		case unsigned:
+			t, null = "uint8", "sql.NullInt64"
+		default:
+			t, null = "int8", "sql.NullInt64"
+		}
+	case "bool", "boolean":
+		t, null = "bool", "sql.NullBool"
+	case "smallint":
+		t, null = "int16", "sql.NullInt64"
+		if unsigned {
+			t = "uint16"
+		}
+	case "mediumint", "int", "integer", "year":
+		t, null = "int", "sql.NullInt64"
+		if unsigned {
+			t = "uint"
+		}
+	case "bigint", "bit":
+		t, null = "int64", "sql.NullInt64"
+		if unsigned {
+			t = "uint64"
+		}
+	case "float":
+		t, null = "float32", "sql.NullFloat64"
+	case "double", "real":
+		t, null = "float64", "sql.NullFloat64"
+	case "date", "datetime", "timestamp":
+		t, null = "time.Time", "sql.NullTime"
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		// nil represents null
+		return "[]byte", ""
+	default:
+		// decimal is kept as string to avoid losing precision
+		t, null = "string", "sql.NullString"
+	}
+	if !c.Nullable {
+		if t == "time.Time" {
+			return t, "time"
+		}
+		return t, ""
+	}
+	if pointers {
+		if t == "time.Time" {
+			return "*" + t, "time"
+		}
+		return "*" + t, ""
+	}
+	return null, "database/sql"
+}
+
+// GenerateModels generates source of model structs for tables
+func GenerateModels(tables []TableDef, opts ModelOptions) ([]byte, error) {
+	imports := make(map[string]bool)
+	body := bytes.Buffer{}
+	for _, t := range tables {
+		name := GoName(t.Name)
+		fmt.Fprintf(&body, "// %s is the model of table `%s`\n", name, t.Name)
+		fmt.Fprintf(&body, "type %s struct {\n", name)
+		var primaries []string
+		for _, c := range t.Columns {
+			field := GoName(c.Name)
+			typ, pkg := goType(c, opts.Pointers)
+			if pkg != "" {
+				imports[pkg] = true
+			}
+			var tags []string
+			if c.Primary {
+				tags = append(tags, "primary")
+				primaries = append(primaries, field)
+			}
+			if c.AutoIncrement {
+				tags = append(tags, "auto_increment")
+			}
+			if strutils.ToUnderscore(field) != c.Name {
+				tags = append(tags, "column="+c.Name)
+			}
+			if len(tags) > 0 {
+				fmt.Fprintf(&body, "\t%s %s `dao:\"%s\"`\n", field, typ, strings.Join(tags, ";"))
+			} else {
+				fmt.Fprintf(&body, "\t%s %s\n", field, typ)
+			}
+		}
+		body.WriteString("}\n\n")
+		if opts.Dao {
+			if len(primaries) == 0 {
+				return nil, fmt.Errorf("No primary key found in table %s", t.Name)
+			}
+			imports["context"] = true
+			imports["database/sql"] = true
+			imports["github.com/jasonjoo2010/godao"] = true
+			imports["github.com/jasonjoo2010/godao/options"] = true
+			imports["github.com/jasonjoo2010/godao/query"] = true
+			generateDao(&body, name, t.Name)
+		}
+	}
+
+	src := bytes.Buffer{}
+	src.WriteString("// Code generated by godao-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\n", opts.Package)
+	if len(imports) > 0 {
+		pkgs := make([]string, 0, len(imports))
+		for pkg := range imports {
+			pkgs = append(pkgs, pkg)
+		}
+		// standard packages first
+		sort.Slice(pkgs, func(i, j int) bool {
+			a, b := strings.Contains(pkgs[i], "."), strings.Contains(pkgs[j], ".")
+			if a != b {
+				return b
+			}
+			return pkgs[i] < pkgs[j]
+		})
+		src.WriteString("import (\n")
+		for i, pkg := range pkgs {
+			if i > 0 && strings.Contains(pkg, ".") && !strings.Contains(pkgs[i-1], ".") {
+				src.WriteString("\n")
+			}
+			fmt.Fprintf(&src, "\t%q\n", pkg)
+		}
+		src.WriteString(")\n\n")
+	}
+	src.Write(body.Bytes())
+	return format.Source(src.Bytes())
+}
+
+func generateDao(b *bytes.Buffer, name, table string) {
+	fmt.Fprintf(b, `// %[1]sDao is the typed dao of %[1]s
+type %[1]sDao struct {
+	*godao.Dao
+}
+
+// New%[1]sDao creates the dao of table `+"`%[2]s`"+`
+func New%[1]sDao(db *sql.DB, opts ...options.DaoOption) *%[1]sDao {
+	opts = append([]options.DaoOption{options.WithTable(%[2]q)}, opts...)
+	return &%[1]sDao{godao.NewDao(%[1]s{}, db, opts...)}
+}
+
+// SelectOne returns the %[1]s or nil specified by primary
+func (dao *%[1]sDao) SelectOne(ctx context.Context, id interface{}, opts ...options.SelectOption) (*%[1]s, error) {
+	obj, err := dao.Dao.SelectOne(ctx, id, opts...)
+	if err != nil || obj == nil {
+		return nil, err
+	}
+	return obj.(*%[1]s), nil
+}
+
+// Select returns %[1]s objects matched by the condition
+func (dao *%[1]sDao) Select(ctx context.Context, data query.Data, opts ...options.SelectOption) ([]*%[1]s, error) {
+	list, err := dao.Dao.Select(ctx, data, opts...)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*%[1]s, len(list))
+	for i, obj := range list {
+		result[i] = obj.(*%[1]s)
+	}
+	return result, nil
+}
+
+`, name, table)
+}
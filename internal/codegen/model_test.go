@@ -0,0 +1,50 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoName(t *testing.T) {
+	assert.Equal(t, "UserId", GoName("user_id"))
+	assert.Equal(t, "Email", GoName("Email"))
+	assert.Equal(t, "F2fa", GoName("2fa"))
+	assert.Equal(t, "OrderItem", GoName("order-item"))
+}
+
+func TestGenerateModels(t *testing.T) {
+	defs, err := ParseDDL(dump)
+	assert.Nil(t, err)
+
+	src, err := GenerateModels(defs[:1], ModelOptions{Package: "model"})
+	assert.Nil(t, err)
+	assert.Equal(t, "// Code generated by godao-gen. DO NOT EDIT.\n"+
+		"\n"+
+		"package model\n"+
+		"\n"+
+		"import (\n"+
+		"\t\"database/sql\"\n"+
+		"\t\"time\"\n"+
+		")\n"+
+		"\n"+
+		"// UserInfo is the model of table `user_info`\n"+
+		"type UserInfo struct {\n"+
+		"\tId       uint64 `dao:\"primary;auto_increment\"`\n"+
+		"\tNickName string\n"+
+		"\tEmail    sql.NullString `dao:\"column=Email\"`\n"+
+		"\tScore    string\n"+
+		"\tCreated  time.Time\n"+
+		"}\n", string(src))
+
+	src, err = GenerateModels(defs, ModelOptions{Package: "model", Pointers: true, Dao: true})
+	assert.Nil(t, err)
+	assert.Contains(t, string(src), "\tEmail    *string `dao:\"column=Email\"`\n")
+	assert.Contains(t, string(src), "func NewUserInfoDao(db *sql.DB, opts ...options.DaoOption) *UserInfoDao {")
+	assert.Contains(t, string(src), "options.WithTable(\"relation\")")
+	assert.Contains(t, string(src), "\tUid    int64 `dao:\"primary\"`\n")
+}
@@ -0,0 +1,145 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package codegen
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jasonjoo2010/godao/schema"
+)
+
+var (
+	createPattern  = regexp.MustCompile("(?i)^\\s*create\\s+table\\s+(?:if\\s+not\\s+exists\\s+)?(?:`?\\w+`?\\.)?`?(\\w+)`?\\s*\\(")
+	columnPattern  = regexp.MustCompile("(?i)^\\s*`?(\\w+)`?\\s+([a-z]+(?:\\([^)]*\\))?(?:\\s+unsigned)?)(.*)$")
+	primaryPattern = regexp.MustCompile("(?i)^\\s*(?:constraint\\s+\\S+\\s+)?primary\\s+key\\s*\\(([^)]*)\\)")
+	indexPattern   = regexp.MustCompile("(?i)^\\s*(unique\\s+)?(?:key|index)\\s+`?(\\w+)`?\\s*\\(([^)]*)\\)")
+	skipPattern    = regexp.MustCompile("(?i)^\\s*(?:constraint|foreign|fulltext|spatial|check)\\b")
+)
+
+// TableDef is the definition of a table read from database or dump
+type TableDef struct {
+	Name    string
+	Columns []schema.Column
+	Indexes []schema.Index
+}
+
+// ReadDB reads definitions of tables in current database, or all tables if none given
+func ReadDB(ctx context.Context, db *sql.DB, tables ...string) ([]TableDef, error) {
+	if len(tables) == 0 {
+		rows, err := db.QueryContext(ctx, "select `table_name` from `information_schema`.`tables` where `table_schema` = database() and `table_type` = 'BASE TABLE' order by `table_name`")
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var name string
+			if err = rows.Scan(&name); err != nil {
+				return nil, err
+			}
+			tables = append(tables, name)
+		}
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	defs := make([]TableDef, 0, len(tables))
+	for _, name := range tables {
+		t, err := schema.ReadTable(ctx, db, name)
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			return nil, fmt.Errorf("Table %s doesn't exist", name)
+		}
+		defs = append(defs, TableDef{Name: name, Columns: t.Columns, Indexes: t.Indexes})
+	}
+	return defs, nil
+}
+
+func splitColumns(str string) []string {
+	arr := strings.Split(str, ",")
+	columns := make([]string, 0, len(arr))
+	for _, c := range arr {
+		c = strings.TrimSpace(c)
+		// prefix length like `name`(10)
+		if i := strings.IndexByte(c, '('); i > 0 {
+			c = c[:i]
+		}
+		columns = append(columns, strings.Trim(c, "` "))
+	}
+	return columns
+}
+
+// ParseDDL parses `CREATE TABLE` statements in a dump, e.g. output of mysqldump --no-data.
+//	Definitions are expected line by line as formatted by MySQL.
+func ParseDDL(src string) ([]TableDef, error) {
+	var (
+		defs    []TableDef
+		current *TableDef
+	)
+	scanner := bufio.NewScanner(strings.NewReader(src))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(strings.TrimSpace(scanner.Text()), ",")
+		if current == nil {
+			if match := createPattern.FindStringSubmatch(line); match != nil {
+				defs = append(defs, TableDef{Name: match[1]})
+				current = &defs[len(defs)-1]
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ")") {
+			if len(current.Columns) == 0 {
+				return nil, fmt.Errorf("No column found in table %s", current.Name)
+			}
+			current = nil
+			continue
+		}
+		if match := primaryPattern.FindStringSubmatch(line); match != nil {
+			for _, name := range splitColumns(match[1]) {
+				for i := range current.Columns {
+					if strings.EqualFold(current.Columns[i].Name, name) {
+						current.Columns[i].Primary = true
+					}
+				}
+			}
+			continue
+		}
+		if match := indexPattern.FindStringSubmatch(line); match != nil {
+			current.Indexes = append(current.Indexes, schema.Index{
+				Name:    match[2],
+				Unique:  match[1] != "",
+				Columns: splitColumns(match[3]),
+			})
+			continue
+		}
+		if skipPattern.MatchString(line) {
+			continue
+		}
+		if match := columnPattern.FindStringSubmatch(line); match != nil {
+			rest := strings.ToLower(match[3])
+			c := schema.Column{
+				Name:          match[1],
+				Type:          strings.ToLower(match[2]),
+				Nullable:      !strings.Contains(rest, "not null"),
+				AutoIncrement: strings.Contains(rest, "auto_increment"),
+			}
+			c.HasDefault = c.Nullable || strings.Contains(rest, "default ")
+			current.Columns = append(current.Columns, c)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if current != nil {
+		return nil, fmt.Errorf("Definition of table %s is incomplete", current.Name)
+	}
+	return defs, nil
+}
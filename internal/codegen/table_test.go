@@ -0,0 +1,54 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package codegen
+
+import (
+	"testing"
+
+	"github.com/jasonjoo2010/godao/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+const dump = "-- MySQL dump\n" +
+	"DROP TABLE IF EXISTS `user_info`;\n" +
+	"CREATE TABLE `user_info` (\n" +
+	"  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,\n" +
+	"  `nick_name` varchar(100) NOT NULL DEFAULT '',\n" +
+	"  `Email` varchar(255) DEFAULT NULL,\n" +
+	"  `score` decimal(10,2) NOT NULL,\n" +
+	"  `created` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,\n" +
+	"  PRIMARY KEY (`id`),\n" +
+	"  UNIQUE KEY `uk_email` (`Email`),\n" +
+	"  KEY `idx_nick` (`nick_name`(10))\n" +
+	") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n" +
+	"\n" +
+	"CREATE TABLE IF NOT EXISTS `relation` (\n" +
+	"  `uid` bigint NOT NULL,\n" +
+	"  `follow` bigint NOT NULL,\n" +
+	"  PRIMARY KEY (`uid`, `follow`)\n" +
+	");\n"
+
+func TestParseDDL(t *testing.T) {
+	defs, err := ParseDDL(dump)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(defs))
+	assert.Equal(t, "user_info", defs[0].Name)
+	assert.Equal(t, []schema.Column{
+		{Name: "id", Type: "bigint(20) unsigned", Primary: true, AutoIncrement: true},
+		{Name: "nick_name", Type: "varchar(100)", HasDefault: true},
+		{Name: "Email", Type: "varchar(255)", Nullable: true, HasDefault: true},
+		{Name: "score", Type: "decimal(10,2)"},
+		{Name: "created", Type: "datetime", HasDefault: true},
+	}, defs[0].Columns)
+	assert.Equal(t, []schema.Index{
+		{Name: "uk_email", Unique: true, Columns: []string{"Email"}},
+		{Name: "idx_nick", Columns: []string{"nick_name"}},
+	}, defs[0].Indexes)
+	assert.True(t, defs[1].Columns[0].Primary)
+	assert.True(t, defs[1].Columns[1].Primary)
+
+	_, err = ParseDDL("CREATE TABLE `broken` (\n  `id` int")
+	assert.NotNil(t, err)
+}
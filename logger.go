@@ -0,0 +1,94 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"time"
+
+	"github.com/jasonjoo2010/godao/options"
+	"github.com/jasonjoo2010/godao/types"
+	"github.com/sirupsen/logrus"
+)
+
+// LogrusLogger logs through logrus.
+//	Statements are logged in debug level, slow ones in warn level and failed ones in error level.
+type LogrusLogger struct {
+	Logger *logrus.Logger
+}
+
+// NewLogrusLogger creates a logger based on the standard logger of logrus
+func NewLogrusLogger() *LogrusLogger {
+	return &LogrusLogger{
+		Logger: logrus.StandardLogger(),
+	}
+}
+
+func (l *LogrusLogger) Statement(ctx context.Context, log *types.StatementLog) {
+	level := logrus.DebugLevel
+	switch {
+	case log.Err != nil:
+		level = logrus.ErrorLevel
+	case log.Slow:
+		level = logrus.WarnLevel
+	}
+	if !l.Logger.IsLevelEnabled(level) {
+		return
+	}
+	fields := logrus.Fields{
+		"operation": log.Operation.String(),
+		"table":     log.Table,
+		"sql":       log.SQL,
+		"args_cnt":  log.ArgsCount,
+		"duration":  log.Duration,
+		"affected":  log.RowsAffected,
+	}
+	if log.Args != nil {
+		fields["args"] = log.Args
+	}
+	entry := l.Logger.WithFields(fields)
+	switch {
+	case log.Err != nil:
+		entry.WithError(log.Err).Error("Statement failed")
+	case log.Slow:
+		entry.Warn("Slow statement")
+	default:
+		entry.Debug("Statement executed")
+	}
+}
+
+func (l *LogrusLogger) Warn(msg string, err error) {
+	l.Logger.Warn(msg, ": ", err.Error())
+}
+
+// loggingInterceptor logs the final statement actually executed
+func loggingInterceptor(logger types.Logger, cfg *options.DaoOptions) types.Interceptor {
+	logArgs, redactor, threshold := cfg.LogArgs, cfg.ArgsRedactor, cfg.SlowThreshold
+	return func(ctx context.Context, stmt *types.Statement, next types.Invoker) (*types.Result, error) {
+		start := time.Now()
+		result, err := next(ctx, stmt)
+		log := &types.StatementLog{
+			Operation: stmt.Operation,
+			Table:     stmt.Table,
+			SQL:       stmt.SQL,
+			ArgsCount: len(stmt.Args),
+			Duration:  time.Since(start),
+			Err:       err,
+		}
+		log.Slow = threshold > 0 && log.Duration >= threshold
+		if result != nil {
+			log.RowsAffected = result.RowsAffected
+		}
+		if logArgs {
+			if redactor != nil {
+				log.Args = redactor(stmt.Args)
+			} else {
+				log.Args = stmt.Args
+			}
+		}
+		logger.Statement(ctx, log)
+		return result, err
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jasonjoo2010/godao/options"
+	"github.com/jasonjoo2010/godao/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordLogger struct {
+	logs  []*types.StatementLog
+	warns []string
+}
+
+func (l *recordLogger) Statement(ctx context.Context, log *types.StatementLog) {
+	l.logs = append(l.logs, log)
+}
+
+func (l *recordLogger) Warn(msg string, err error) {
+	l.warns = append(l.warns, msg)
+}
+
+func TestStatementLogging(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	logger := &recordLogger{}
+	dao := NewDao(Demo{}, db,
+		options.WithLogger(logger),
+		options.WithSlowThreshold(50*time.Millisecond),
+		options.WithLogArgs(func(args []interface{}) []interface{} {
+			return []interface{}{"***"}
+		}),
+	)
+
+	mock.ExpectExec("delete from `demo` where `id` = ?").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("delete from `demo` where `id` = ?").
+		WithArgs(2).
+		WillDelayFor(60 * time.Millisecond).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	dao.Delete(context.Background(), 1)
+	dao.Delete(context.Background(), 2)
+
+	assert.Equal(t, 2, len(logger.logs))
+	log := logger.logs[0]
+	assert.Equal(t, types.OperationDelete, log.Operation)
+	assert.Equal(t, "demo", log.Table)
+	assert.Equal(t, "delete from `demo` where `id` = ?", log.SQL)
+	assert.Equal(t, 1, log.ArgsCount)
+	assert.Equal(t, []interface{}{"***"}, log.Args)
+	assert.Equal(t, int64(1), log.RowsAffected)
+	assert.Nil(t, log.Err)
+	assert.False(t, log.Slow)
+
+	log = logger.logs[1]
+	assert.Equal(t, int64(0), log.RowsAffected)
+	assert.True(t, log.Slow)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestFailureLoggedOnce(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	logger := &recordLogger{}
+	dao := NewDao(Demo{}, db, options.WithLogger(logger))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("insert into `demo` (`id`, `name`, `value`, `cnt`, `created`) values (?, ?, ?, ?, ?);").
+		WillReturnError(errors.New("duplicated"))
+	mock.ExpectCommit()
+	affected, _, err := dao.Insert(context.Background(), &Demo{Id: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), affected)
+
+	assert.Equal(t, 1, len(logger.logs))
+	assert.NotNil(t, logger.logs[0].Err)
+	assert.Empty(t, logger.warns)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,26 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"time"
+
+	"github.com/jasonjoo2010/godao/types"
+)
+
+// metricsInterceptor reports every statement executed to the collector
+func metricsInterceptor(collector types.MetricsCollector) types.Interceptor {
+	return func(ctx context.Context, stmt *types.Statement, next types.Invoker) (*types.Result, error) {
+		start := time.Now()
+		result, err := next(ctx, stmt)
+		var rows int64
+		if result != nil {
+			rows = result.RowsAffected
+		}
+		collector.Observe(stmt.Operation, stmt.Table, time.Since(start), rows, err)
+		return result, err
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jasonjoo2010/godao/options"
+	"github.com/jasonjoo2010/godao/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordCollector struct {
+	ops    []types.Operation
+	rows   int64
+	errors int
+}
+
+func (c *recordCollector) Observe(op types.Operation, table string, duration time.Duration, rows int64, err error) {
+	c.ops = append(c.ops, op)
+	c.rows += rows
+	if err != nil {
+		c.errors++
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	collector := &recordCollector{}
+	dao := NewDao(Demo{}, db, options.WithMetrics(collector))
+
+	mock.ExpectQuery("select `id` as `Id`, `name` as `Name`, `value` as `Value`, `cnt` as `Cnt`, `created` as `Created` from `demo` where `cnt` = ?;").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"Id", "Name", "Value", "Cnt", "Created"}).
+			AddRow(1, "n1", "v1", 1, 0).
+			AddRow(2, "n2", "v2", 1, 0))
+	mock.ExpectBegin()
+	mock.ExpectExec("insert into `demo` (`id`, `name`, `value`, `cnt`, `created`) values (?, ?, ?, ?, ?);").
+		WillReturnError(errors.New("duplicated"))
+	mock.ExpectCommit()
+
+	list, err := dao.Select(context.Background(), (&Query{}).Equal("Cnt", 1).Data())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(list))
+	dao.Insert(context.Background(), &Demo{})
+
+	assert.Equal(t, []types.Operation{types.OperationSelect, types.OperationInsert}, collector.ops)
+	assert.Equal(t, int64(2), collector.rows)
+	assert.Equal(t, 1, collector.errors)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
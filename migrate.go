@@ -0,0 +1,58 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+
+	"github.com/jasonjoo2010/godao/options"
+	"github.com/jasonjoo2010/godao/query"
+	"github.com/jasonjoo2010/godao/schema"
+	"github.com/jasonjoo2010/godao/types"
+)
+
+// Migrate compares the live table with the model and alters it.
+//	The table is created if it doesn't exist. Only non-destructive changes are applied
+//	unless options.WithDestructive() is given, and nothing is applied in dry-run.
+//	Types are not modified even if widening unless options.WithTypeChanges() is given.
+//	All changes planned are returned and marked whether applied. Only MySQL is supported.
+func (dao *Dao) Migrate(ctx context.Context, opts ...options.MigrateOption) (changes []schema.Change, err error) {
+	ctx, finish := dao.observe(ctx, "Migrate")
+	defer finish(&err)
+	cfg := options.MigrateOptions{}
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+	shards, err := dao.route(ctx, &query.Data{})
+	if err != nil {
+		return nil, err
+	}
+	var result []schema.Change
+	for _, shard := range shards {
+		e, err := dao.shardExecutor(ctx, shard)
+		if err != nil {
+			return result, err
+		}
+		live, err := schema.ReadTable(ctx, e, shard.Table)
+		if err != nil {
+			return result, err
+		}
+		changes, err := schema.Diff(shard.Table, dao.fields, live)
+		if err != nil {
+			return result, err
+		}
+		for _, change := range changes {
+			safe := !change.Destructive && (!change.TypeChanged || cfg.AllowTypeChanges)
+			if !cfg.DryRun && (safe || cfg.AllowDestructive) {
+				if _, err = dao.exec(ctx, e, types.OperationDDL, shard.Table, change.SQL); err != nil {
+					return result, err
+				}
+				change.Applied = true
+			}
+			result = append(result, change)
+		}
+	}
+	return result, nil
+}
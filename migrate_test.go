@@ -0,0 +1,118 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jasonjoo2010/godao/options"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	liveColumnsSQL = "select `column_name`, `column_type`, `is_nullable`, `column_default`, `column_key`, `extra` from `information_schema`.`columns` where `table_schema` = database() and `table_name` = ? order by `ordinal_position`"
+	liveIndexesSQL = "select `index_name`, `non_unique`, `column_name` from `information_schema`.`statistics` where `table_schema` = database() and `table_name` = ? and `index_name` <> 'PRIMARY' order by `index_name`, `seq_in_index`"
+)
+
+var (
+	liveColumns = []string{"column_name", "column_type", "is_nullable", "column_default", "column_key", "extra"}
+)
+
+func TestMigrate(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(Demo{}, db)
+	expectLive := func() {
+		mock.ExpectQuery(liveColumnsSQL).WithArgs("demo").
+			WillReturnRows(sqlmock.NewRows(liveColumns).
+				AddRow("id", "bigint(20)", "NO", nil, "PRI", "auto_increment").
+				AddRow("name", "varchar(100)", "NO", "", "MUL", "").
+				AddRow("cnt", "int(11)", "NO", "0", "", "").
+				AddRow("created", "bigint(20)", "NO", "0", "", "").
+				AddRow("legacy", "int(11)", "YES", nil, "", ""))
+		mock.ExpectQuery(liveIndexesSQL).WithArgs("demo").
+			WillReturnRows(sqlmock.NewRows([]string{"index_name", "non_unique", "column_name"}).
+				AddRow("name", 1, "name"))
+	}
+
+	// dry run
+	expectLive()
+	changes, err := dao.Migrate(context.Background(), options.WithDryRun())
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(changes))
+	for _, c := range changes {
+		assert.False(t, c.Applied)
+	}
+
+	// non-destructive changes only, and types are kept
+	expectLive()
+	mock.ExpectExec("alter table `demo` add column `value` varchar(255) not null default '' after `name`").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	changes, err = dao.Migrate(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "alter table `demo` add column `value` varchar(255) not null default '' after `name`", changes[0].SQL)
+	assert.True(t, changes[0].Applied)
+	assert.Equal(t, "alter table `demo` modify column `cnt` bigint not null default 0", changes[1].SQL)
+	assert.False(t, changes[1].Destructive)
+	assert.True(t, changes[1].TypeChanged)
+	assert.False(t, changes[1].Applied)
+	assert.Equal(t, "alter table `demo` drop column `legacy`", changes[2].SQL)
+	assert.False(t, changes[2].Applied)
+
+	// widening types is opt-in
+	expectLive()
+	mock.ExpectExec("alter table `demo` add column `value` varchar(255) not null default '' after `name`").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("alter table `demo` modify column `cnt` bigint not null default 0").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	changes, err = dao.Migrate(context.Background(), options.WithTypeChanges())
+	assert.Nil(t, err)
+	assert.True(t, changes[1].Applied)
+	assert.False(t, changes[2].Applied)
+
+	// create table missing
+	mock.ExpectQuery(liveColumnsSQL).WithArgs("demo").
+		WillReturnRows(sqlmock.NewRows(liveColumns))
+	sqlStr, _ := dao.CreateTableSQL()
+	mock.ExpectExec(sqlStr).WillReturnResult(sqlmock.NewResult(0, 0))
+	changes, err = dao.Migrate(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(changes))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestVerify(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	expectLive := func(cntType string) {
+		mock.ExpectQuery(liveColumnsSQL).WithArgs("demo").
+			WillReturnRows(sqlmock.NewRows(liveColumns).
+				AddRow("id", "bigint(20)", "NO", nil, "PRI", "auto_increment").
+				AddRow("name", "varchar(100)", "NO", "", "MUL", "").
+				AddRow("value", "varchar(255)", "NO", "", "", "").
+				AddRow("cnt", cntType, "NO", "0", "", "").
+				AddRow("created", "bigint(20)", "NO", "0", "", ""))
+		mock.ExpectQuery(liveIndexesSQL).WithArgs("demo").
+			WillReturnRows(sqlmock.NewRows([]string{"index_name", "non_unique", "column_name"}))
+	}
+
+	expectLive("int(11)")
+	dao := NewDao(Demo{}, db, options.WithVerify())
+
+	expectLive("varchar(10)")
+	err := dao.Verify(context.Background())
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "field Cnt: type int is incompatible with column `cnt` varchar(10)")
+
+	expectLive("tinyint(4)")
+	assert.Nil(t, dao.Verify(context.Background()))
+
+	expectLive("datetime")
+	assert.Panics(t, func() { NewDao(Demo{}, db, options.WithVerify()) })
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
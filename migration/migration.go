@@ -0,0 +1,152 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	filePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+)
+
+// Func migrates in the transaction given
+type Func func(ctx context.Context, tx *sql.Tx) error
+
+// Migration is a numbered step of schema evolution.
+//	Up and Down can be Go functions or statements in SQL.
+type Migration struct {
+	Version int64
+	Name    string
+
+	Up   Func
+	Down Func
+}
+
+// SQL creates a migration executing statements in SQL.
+//	Multiple statements are separated by semicolons at the end of lines.
+func SQL(version int64, name, up, down string) *Migration {
+	m := &Migration{
+		Version: version,
+		Name:    name,
+		Up:      execFunc(up),
+	}
+	if strings.TrimSpace(down) != "" {
+		m.Down = execFunc(down)
+	}
+	return m
+}
+
+// Go creates a migration with Go functions, down could be nil if it's irreversible
+func Go(version int64, name string, up, down Func) *Migration {
+	return &Migration{
+		Version: version,
+		Name:    name,
+		Up:      up,
+		Down:    down,
+	}
+}
+
+func execFunc(sqlStr string) Func {
+	statements := splitStatements(sqlStr)
+	return func(ctx context.Context, tx *sql.Tx) error {
+		for _, stmt := range statements {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// splitStatements splits statements by semicolons at the end of lines
+func splitStatements(sqlStr string) []string {
+	var (
+		statements []string
+		b          strings.Builder
+	)
+	for _, line := range strings.Split(sqlStr, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if b.Len() == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(line)
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(b.String()), ";"))
+			b.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(b.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+// LoadDir loads migrations from files named like `0001_create_user.up.sql` and
+//	`0001_create_user.down.sql` in dir. Down file is optional.
+func LoadDir(dir string) ([]*Migration, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type pair struct {
+		name     string
+		up, down string
+		hasUp    bool
+	}
+	pairs := make(map[int64]*pair)
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		match := filePattern.FindStringSubmatch(f.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		p, ok := pairs[version]
+		if !ok {
+			p = &pair{name: match[2]}
+			pairs[version] = p
+		} else if p.name != match[2] {
+			return nil, fmt.Errorf("Names of migration %d are inconsistent: %s, %s", version, p.name, match[2])
+		}
+		if match[3] == "up" {
+			p.up = string(content)
+			p.hasUp = true
+		} else {
+			p.down = string(content)
+		}
+	}
+	migrations := make([]*Migration, 0, len(pairs))
+	for version, p := range pairs {
+		if !p.hasUp {
+			return nil, fmt.Errorf("Up file of migration %d is missing", version)
+		}
+		migrations = append(migrations, SQL(version, p.name, p.up, p.down))
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
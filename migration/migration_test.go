@@ -0,0 +1,56 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package migration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitStatements(t *testing.T) {
+	assert.Equal(t, []string{
+		"create table a (\n  id bigint\n)",
+		"insert into a values (1)",
+		"insert into a values (2)",
+	}, splitStatements(`
+-- comment
+create table a (
+  id bigint
+);
+
+insert into a values (1);
+insert into a values (2)
+`))
+}
+
+func TestLoadDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrations")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	write := func(name, content string) {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write("0002_add_name.up.sql", "alter table a add name varchar(10);")
+	write("0001_create_a.up.sql", "create table a (id bigint);")
+	write("0001_create_a.down.sql", "drop table a;")
+	write("README.md", "ignored")
+
+	migrations, err := LoadDir(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(migrations))
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "create_a", migrations[0].Name)
+	assert.NotNil(t, migrations[0].Down)
+	assert.Equal(t, int64(2), migrations[1].Version)
+	assert.Nil(t, migrations[1].Down)
+
+	write("0003_broken.down.sql", "select 1;")
+	_, err = LoadDir(dir)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,245 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrLocked is returned when the lock is held by others until timeout
+	ErrLocked = errors.New("Migration lock is held by others")
+)
+
+// Status is the state of a migration
+type Status struct {
+	Version int64
+	Name    string
+	Applied bool
+	// Unix timestamp of applying
+	AppliedAt int64
+	// Whether it's applied but missing in migrations given
+	Missing bool
+}
+
+// Migrator applies migrations to database and records them in bookkeeping table.
+//	Concurrent migrators are serialized by an advisory lock of MySQL.
+type Migrator struct {
+	db          *sql.DB
+	table       string
+	lockTimeout time.Duration
+	migrations  []*Migration
+}
+
+type Option func(m *Migrator)
+
+// WithTable specifies the bookkeeping table, default is `schema_migrations`
+func WithTable(table string) Option {
+	return func(m *Migrator) {
+		m.table = table
+	}
+}
+
+// WithLockTimeout specifies how long to wait for the lock, default is 1 minute
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(m *Migrator) {
+		m.lockTimeout = timeout
+	}
+}
+
+// New creates a migrator running migrations on db in order of version
+func New(db *sql.DB, migrations []*Migration, opts ...Option) *Migrator {
+	m := &Migrator{
+		db:          db,
+		table:       "schema_migrations",
+		lockTimeout: time.Minute,
+		migrations:  make([]*Migration, len(migrations)),
+	}
+	for _, fn := range opts {
+		fn(m)
+	}
+	copy(m.migrations, migrations)
+	sort.Slice(m.migrations, func(i, j int) bool {
+		return m.migrations[i].Version < m.migrations[j].Version
+	})
+	for i, migration := range m.migrations {
+		if migration.Up == nil {
+			panic(fmt.Sprintf("Up of migration %d is missing", migration.Version))
+		}
+		if i > 0 && m.migrations[i-1].Version == migration.Version {
+			panic(fmt.Sprintf("Duplicated version of migration: %d", migration.Version))
+		}
+	}
+	return m
+}
+
+// session runs fn on a dedicated connection holding the lock
+func (m *Migrator) session(ctx context.Context, fn func(conn *sql.Conn, applied map[int64]Status) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	lockName := "godao:" + m.table
+	var locked sql.NullInt64
+	err = conn.QueryRowContext(ctx, "select get_lock(?, ?)", lockName, int64(m.lockTimeout/time.Second)).Scan(&locked)
+	if err != nil {
+		return
+	}
+	if locked.Int64 != 1 {
+		return ErrLocked
+	}
+	defer func() {
+		if _, e := conn.ExecContext(context.Background(), "do release_lock(?)", lockName); e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	_, err = conn.ExecContext(ctx, "create table if not exists `"+m.table+"` ("+
+		"`version` bigint not null, "+
+		"`name` varchar(255) not null, "+
+		"`applied_at` bigint not null, "+
+		"primary key (`version`))")
+	if err != nil {
+		return
+	}
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return
+	}
+	return fn(conn, applied)
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]Status, error) {
+	rows, err := conn.QueryContext(ctx, "select `version`, `name`, `applied_at` from `"+m.table+"`")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int64]Status)
+	for rows.Next() {
+		s := Status{Applied: true}
+		if err = rows.Scan(&s.Version, &s.Name, &s.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[s.Version] = s
+	}
+	return applied, rows.Err()
+}
+
+// run applies up or down of migration with bookkeeping in one transaction.
+//	Pay attention that DDL statements are committed implicitly in MySQL.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, migration *Migration, up bool) (err error) {
+	fn := migration.Up
+	if !up {
+		if migration.Down == nil {
+			return fmt.Errorf("Migration %d is irreversible", migration.Version)
+		}
+		fn = migration.Down
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = fn(ctx, tx); err != nil {
+		return fmt.Errorf("Migration %d failed: %v", migration.Version, err)
+	}
+	if up {
+		_, err = tx.ExecContext(ctx, "insert into `"+m.table+"` (`version`, `name`, `applied_at`) values (?, ?, ?)",
+			migration.Version, migration.Name, time.Now().Unix())
+	} else {
+		_, err = tx.ExecContext(ctx, "delete from `"+m.table+"` where `version` = ?", migration.Version)
+	}
+	if err != nil {
+		return
+	}
+	return tx.Commit()
+}
+
+// Up applies all pending migrations
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.session(ctx, func(conn *sql.Conn, applied map[int64]Status) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.run(ctx, conn, migration, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Down rolls back the latest migration applied
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.session(ctx, func(conn *sql.Conn, applied map[int64]Status) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[m.migrations[i].Version]; ok {
+				return m.run(ctx, conn, m.migrations[i], false)
+			}
+		}
+		return nil
+	})
+}
+
+// Goto applies pending migrations not later than version
+//	and rolls back applied ones later than version in reverse order.
+func (m *Migrator) Goto(ctx context.Context, version int64) error {
+	return m.session(ctx, func(conn *sql.Conn, applied map[int64]Status) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; ok && migration.Version > version {
+				if err := m.run(ctx, conn, migration, false); err != nil {
+					return err
+				}
+			}
+		}
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; !ok && migration.Version <= version {
+				if err := m.run(ctx, conn, migration, true); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Status returns states of all migrations in order of version,
+//	including ones applied but missing in migrations given.
+func (m *Migrator) Status(ctx context.Context) (result []Status, err error) {
+	err = m.session(ctx, func(conn *sql.Conn, applied map[int64]Status) error {
+		for _, migration := range m.migrations {
+			s, ok := applied[migration.Version]
+			if !ok {
+				s = Status{Version: migration.Version}
+			}
+			s.Name = migration.Name
+			result = append(result, s)
+			delete(applied, migration.Version)
+		}
+		for _, s := range applied {
+			s.Missing = true
+			result = append(result, s)
+		}
+		sort.Slice(result, func(i, j int) bool {
+			return result[i].Version < result[j].Version
+		})
+		return nil
+	})
+	return
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+func expectSession(mock sqlmock.Sqlmock, applied ...int64) {
+	mock.ExpectQuery("select get_lock(?, ?)").WithArgs("godao:schema_migrations", int64(60)).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(1))
+	mock.ExpectExec("create table if not exists `schema_migrations` (`version` bigint not null, `name` varchar(255) not null, `applied_at` bigint not null, primary key (`version`))").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	rows := sqlmock.NewRows([]string{"version", "name", "applied_at"})
+	for _, v := range applied {
+		rows.AddRow(v, "m", 1600000000)
+	}
+	mock.ExpectQuery("select `version`, `name`, `applied_at` from `schema_migrations`").WillReturnRows(rows)
+}
+
+func expectRun(mock sqlmock.Sqlmock, sqlStr string, version int64, up bool) {
+	mock.ExpectBegin()
+	mock.ExpectExec(sqlStr).WillReturnResult(sqlmock.NewResult(0, 0))
+	if up {
+		mock.ExpectExec("insert into `schema_migrations` (`version`, `name`, `applied_at`) values (?, ?, ?)").
+			WithArgs(version, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	} else {
+		mock.ExpectExec("delete from `schema_migrations` where `version` = ?").
+			WithArgs(version).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+}
+
+func TestMigrator(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	m := New(db, []*Migration{
+		SQL(2, "add_name", "alter table a add name varchar(10)", "alter table a drop name"),
+		SQL(1, "create_a", "create table a (id bigint)", "drop table a"),
+		SQL(3, "add_age", "alter table a add age int", ""),
+	})
+	ctx := context.Background()
+
+	expectSession(mock, 1)
+	expectRun(mock, "alter table a add name varchar(10)", 2, true)
+	expectRun(mock, "alter table a add age int", 3, true)
+	mock.ExpectExec("do release_lock(?)").WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.Nil(t, m.Up(ctx))
+
+	// irreversible
+	expectSession(mock, 1, 2, 3)
+	mock.ExpectExec("do release_lock(?)").WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.NotNil(t, m.Down(ctx))
+
+	expectSession(mock, 1, 2)
+	expectRun(mock, "alter table a drop name", 2, false)
+	expectRun(mock, "drop table a", 1, false)
+	mock.ExpectExec("do release_lock(?)").WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.Nil(t, m.Goto(ctx, 0))
+
+	expectSession(mock, 1, 5)
+	mock.ExpectExec("do release_lock(?)").WillReturnResult(sqlmock.NewResult(0, 0))
+	status, err := m.Status(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []Status{
+		{Version: 1, Name: "create_a", Applied: true, AppliedAt: 1600000000},
+		{Version: 2, Name: "add_name"},
+		{Version: 3, Name: "add_age"},
+		{Version: 5, Name: "m", Applied: true, AppliedAt: 1600000000, Missing: true},
+	}, status)
+
+	// locked by others
+	mock.ExpectQuery("select get_lock(?, ?)").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(0))
+	assert.Equal(t, ErrLocked, m.Up(ctx))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+	assert.Panics(t, func() { New(db, []*Migration{SQL(1, "a", "", ""), SQL(1, "b", "", "")}) })
+}
@@ -7,6 +7,7 @@ package model
 import (
 	"errors"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/jasonjoo2010/enhanced-utils/strutils"
@@ -18,17 +19,35 @@ const (
 	internal_TAG_OMIT  = "omit"
 	internal_TAG_PRI   = "primary"
 	internal_TAG_AUTO  = "auto_increment"
+	internal_TAG_VER   = "version"
+	internal_TAG_TNT   = "tenant"
+	internal_TAG_NZ    = "nullzero"
 	internal_TAG_FIELD = "column="
+	internal_TAG_SIZE  = "size="
+	internal_TAG_NULL  = "nullable"
+	internal_TAG_DEF   = "default="
+	internal_TAG_IDX   = "index="
+	internal_TAG_UNI   = "unique="
 )
 
 // parseField parses single field's tag.
 func parseField(f reflect.StructField) *types.ModelField {
-	arr := strings.Split(f.Tag.Get(internal_TAG_KEY), ";")
-	field := &types.ModelField{}
+	field := ParseTag(f.Name, f.Tag)
+	if field == nil {
+		return nil
+	}
 	field.Index = f.Index[0]
-	field.Name = f.Name
-	field.Column = strutils.ToUnderscore(field.Name)
 	field.Type = f.Type
+	return field
+}
+
+// ParseTag parses the tag of field without type information.
+//	It returns nil if the field is omitted.
+func ParseTag(name string, structTag reflect.StructTag) *types.ModelField {
+	arr := strings.Split(structTag.Get(internal_TAG_KEY), ";")
+	field := &types.ModelField{}
+	field.Name = name
+	field.Column = strutils.ToUnderscore(field.Name)
 	for _, tag := range arr {
 		switch {
 		case tag == internal_TAG_AUTO:
@@ -37,8 +56,29 @@ func parseField(f reflect.StructField) *types.ModelField {
 			return nil
 		case tag == internal_TAG_PRI:
 			field.Primary = true
+		case tag == internal_TAG_VER:
+			field.Version = true
+		case tag == internal_TAG_TNT:
+			field.Tenant = true
+		case tag == internal_TAG_NZ:
+			field.NullZero = true
+		case tag == internal_TAG_NULL:
+			field.Nullable = true
 		case strings.HasPrefix(tag, internal_TAG_FIELD):
 			field.Column = tag[len(internal_TAG_FIELD):]
+		case strings.HasPrefix(tag, internal_TAG_SIZE):
+			size, err := strconv.Atoi(tag[len(internal_TAG_SIZE):])
+			if err != nil || size < 1 {
+				panic("Invalid size of field " + name + ": " + tag)
+			}
+			field.Size = size
+		case strings.HasPrefix(tag, internal_TAG_DEF):
+			field.Default = tag[len(internal_TAG_DEF):]
+			field.HasDefault = true
+		case strings.HasPrefix(tag, internal_TAG_IDX):
+			field.Indexes = append(field.Indexes, tag[len(internal_TAG_IDX):])
+		case strings.HasPrefix(tag, internal_TAG_UNI):
+			field.Uniques = append(field.Uniques, tag[len(internal_TAG_UNI):])
 		}
 	}
 	return field
@@ -111,6 +151,7 @@ func RealPointer(obj interface{}) interface{} {
 
 // Flatten flattens model object into given array.
 //	Array should have the length of fields.
+//	Zero values of `nullzero` fields are flattened as nil.
 func Flatten(dst []interface{}, typ reflect.Type, fields []*types.ModelField, obj interface{}) error {
 	if len(dst) != len(fields) {
 		return errors.New("dst doesn't have the same length as fields has")
@@ -121,7 +162,12 @@ func Flatten(dst []interface{}, typ reflect.Type, fields []*types.ModelField, ob
 	}
 	val := reflect.ValueOf(obj)
 	for i, f := range fields {
-		dst[i] = val.Field(f.Index).Interface()
+		v := val.Field(f.Index)
+		if f.NullZero && v.IsZero() {
+			dst[i] = nil
+			continue
+		}
+		dst[i] = v.Interface()
 	}
 	return nil
 }
@@ -129,6 +175,7 @@ func Flatten(dst []interface{}, typ reflect.Type, fields []*types.ModelField, ob
 // Pack packs the flatten values to struct object
 //	Pay attention that `dst` should be passed by reference to get the correct state outside.
 //	Passing reference to reduce memory footprints in some scenarios.
+//	Nil values are packed as zero values and pointer fields accept values of their element types.
 func Pack(dst interface{}, typ reflect.Type, fields []*types.ModelField, values []interface{}) error {
 	if len(values) != len(fields) {
 		return errors.New("Array of values doesn't have the same length as fields has")
@@ -143,7 +190,21 @@ func Pack(dst interface{}, typ reflect.Type, fields []*types.ModelField, values
 		return errors.New("The type of dst object is unexpected")
 	}
 	for i, f := range fields {
-		dstVal.Elem().Field(f.Index).Set(reflect.ValueOf(values[i]))
+		field := dstVal.Elem().Field(f.Index)
+		if values[i] == nil {
+			field.Set(reflect.Zero(field.Type()))
+			continue
+		}
+		val := reflect.ValueOf(values[i])
+		if field.Kind() == reflect.Ptr && val.Type() != field.Type() && val.Type().AssignableTo(field.Type().Elem()) {
+			ptr := reflect.New(field.Type().Elem())
+			ptr.Elem().Set(val)
+			val = ptr
+		}
+		if !val.Type().AssignableTo(field.Type()) {
+			return errors.New("Value of field " + f.Name + " has an unexpected type " + val.Type().String())
+		}
+		field.Set(val)
 	}
 	return nil
 }
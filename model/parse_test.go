@@ -7,6 +7,7 @@ package model
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -194,3 +195,80 @@ func TestParse(t *testing.T) {
 	assert.True(t, fields4[1].Primary)
 	assert.False(t, fields4[2].Primary)
 }
+
+// Optimistic locking
+type Article struct {
+	Id      int64 `dao:"primary;auto_increment"`
+	Title   string
+	Version int `dao:"version"`
+}
+
+func TestParseVersion(t *testing.T) {
+	fields := Parse(Article{})
+	assert.Equal(t, 3, len(fields))
+	assert.False(t, fields[1].Version)
+	assert.True(t, fields[2].Version)
+	assert.Equal(t, "version", fields[2].Column)
+}
+
+// Schema
+type Account struct {
+	Id       int64  `dao:"primary;auto_increment"`
+	Email    string `dao:"size=100;unique=uk_email"`
+	Nickname string `dao:"size=50;nullable;default=guest;index=idx_name;index=idx_name_age"`
+	Age      int    `dao:"default=0;index=idx_name_age"`
+}
+
+type BadSize struct {
+	Id   int64  `dao:"primary"`
+	Name string `dao:"size=abc"`
+}
+
+func TestParseSchema(t *testing.T) {
+	fields := Parse(Account{})
+	assert.Equal(t, 100, fields[1].Size)
+	assert.Equal(t, []string{"uk_email"}, fields[1].Uniques)
+	assert.False(t, fields[1].Nullable)
+	assert.False(t, fields[1].HasDefault)
+	assert.True(t, fields[2].Nullable)
+	assert.True(t, fields[2].HasDefault)
+	assert.Equal(t, "guest", fields[2].Default)
+	assert.Equal(t, []string{"idx_name", "idx_name_age"}, fields[2].Indexes)
+	assert.Equal(t, "0", fields[3].Default)
+
+	assert.Panics(t, func() { Parse(BadSize{}) })
+}
+
+type NullableDemo struct {
+	Id       int64 `dao:"primary"`
+	Nick     *string
+	Modified *time.Time
+	Score    int `dao:"nullzero"`
+}
+
+func TestNullable(t *testing.T) {
+	nick := "n"
+	obj := &NullableDemo{Id: 1, Nick: &nick}
+	fields := Parse(obj)
+	assert.True(t, fields[3].NullZero)
+
+	values := make([]interface{}, len(fields))
+	assert.Nil(t, Flatten(values, RealType(obj), fields, obj))
+	assert.Equal(t, &nick, values[1])
+	assert.Equal(t, (*time.Time)(nil), values[2])
+	assert.Nil(t, values[3])
+
+	obj.Score = 3
+	assert.Nil(t, Flatten(values, RealType(obj), fields, obj))
+	assert.Equal(t, 3, values[3])
+
+	// nil as zero and pointer fields accept element values
+	packed := &NullableDemo{Score: 8}
+	assert.Nil(t, Pack(packed, RealType(obj), fields, []interface{}{int64(2), "m", nil, nil}))
+	assert.Equal(t, int64(2), packed.Id)
+	assert.Equal(t, "m", *packed.Nick)
+	assert.Nil(t, packed.Modified)
+	assert.Equal(t, 0, packed.Score)
+
+	assert.NotNil(t, Pack(packed, RealType(obj), fields, []interface{}{int64(2), 3, nil, nil}))
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jasonjoo2010/godao/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type NullableDemo struct {
+	Id    int64 `dao:"primary"`
+	Nick  *string
+	Score int64 `dao:"nullzero"`
+}
+
+func TestNullable(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(NullableDemo{}, db)
+	ctx := context.Background()
+	columns := []string{"Id", "Nick", "Score"}
+
+	mock.ExpectQuery("select `id` as `Id`, `nick` as `Nick`, `score` as `Score` from `nullable_demo`;").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, nil, nil).AddRow(2, "b", 3))
+	list, err := dao.Select(ctx, (&Query{}).Data())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(list))
+	assert.Equal(t, &NullableDemo{Id: 1}, list[0])
+	nick := "b"
+	assert.Equal(t, &NullableDemo{Id: 2, Nick: &nick, Score: 3}, list[1])
+
+	// zero value of nullzero field is written as NULL
+	mock.ExpectBegin()
+	mock.ExpectExec("insert into `nullable_demo` (`id`, `nick`, `score`) values (?, ?, ?);").
+		WithArgs(int64(3), nil, nil).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+	_, _, err = dao.Insert(ctx, &NullableDemo{Id: 3})
+	assert.Nil(t, err)
+
+	mock.ExpectExec("update `nullable_demo` set `nick` = null where `id` = ?").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = dao.UpdateBy(ctx, (&Query{}).Equal("Id", 1).Data(), types.NewNull("Nick"))
+	assert.Nil(t, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
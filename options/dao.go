@@ -4,8 +4,45 @@
 
 package options
 
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jasonjoo2010/godao/balancer"
+	"github.com/jasonjoo2010/godao/cache"
+	"github.com/jasonjoo2010/godao/query"
+	"github.com/jasonjoo2010/godao/sharding"
+	"github.com/jasonjoo2010/godao/types"
+)
+
 type DaoOptions struct {
-	Table string
+	Table        string
+	Interceptors []types.Interceptor
+	Observers    []types.CallObserver
+
+	// logging
+	Logger        types.Logger
+	SlowThreshold time.Duration
+	LogArgs       bool
+	ArgsRedactor  func(args []interface{}) []interface{}
+
+	Metrics types.MetricsCollector
+
+	// read / write splitting
+	Replicas []*sql.DB
+	Balancer balancer.Balancer
+
+	Sharding sharding.Strategy
+
+	// scopes
+	DefaultScopes []query.Data
+	Scopes        map[string]query.Data
+
+	Cache cache.Cache
+
+	StmtCacheSize int
+
+	Verify bool
 }
 
 type DaoOption func(opts *DaoOptions)
@@ -16,3 +53,116 @@ func WithTable(name string) DaoOption {
 		opts.Table = name
 	}
 }
+
+// WithInterceptors appends interceptors wrapping every statement executed.
+//	The first one is the outermost.
+func WithInterceptors(interceptors ...types.Interceptor) DaoOption {
+	return func(opts *DaoOptions) {
+		opts.Interceptors = append(opts.Interceptors, interceptors...)
+	}
+}
+
+// WithCallObservers appends observers of every public method of dao invoked.
+//	The first one is the outermost.
+func WithCallObservers(observers ...types.CallObserver) DaoOption {
+	return func(opts *DaoOptions) {
+		opts.Observers = append(opts.Observers, observers...)
+	}
+}
+
+// WithLogger replaces the default logger based on logrus
+func WithLogger(logger types.Logger) DaoOption {
+	return func(opts *DaoOptions) {
+		opts.Logger = logger
+	}
+}
+
+// WithSlowThreshold marks statements taking longer than threshold as slow
+//	which will be logged in a higher level.
+func WithSlowThreshold(threshold time.Duration) DaoOption {
+	return func(opts *DaoOptions) {
+		opts.SlowThreshold = threshold
+	}
+}
+
+// WithLogArgs enables logging arguments of statements.
+//	Arguments will be passed through redactor if it's not nil
+//	to mask sensitive values like passwords.
+func WithLogArgs(redactor func(args []interface{}) []interface{}) DaoOption {
+	return func(opts *DaoOptions) {
+		opts.LogArgs = true
+		opts.ArgsRedactor = redactor
+	}
+}
+
+// WithMetrics specifies the collector observing every statement executed
+func WithMetrics(collector types.MetricsCollector) DaoOption {
+	return func(opts *DaoOptions) {
+		opts.Metrics = collector
+	}
+}
+
+// WithReplicas specifies replicas for reading.
+//	Select and aggregations out of transaction will be sent to replicas.
+func WithReplicas(replicas ...*sql.DB) DaoOption {
+	return func(opts *DaoOptions) {
+		opts.Replicas = append(opts.Replicas, replicas...)
+	}
+}
+
+// WithBalancer specifies how to pick replicas, default is round robin
+func WithBalancer(b balancer.Balancer) DaoOption {
+	return func(opts *DaoOptions) {
+		opts.Balancer = b
+	}
+}
+
+// WithSharding routes rows into shards by the strategy.
+//	Table specified by WithTable() is ignored in sharding.
+func WithSharding(strategy sharding.Strategy) DaoOption {
+	return func(opts *DaoOptions) {
+		opts.Sharding = strategy
+	}
+}
+
+// WithDefaultScope appends conditions merged into every selection, aggregation,
+//	updating by condition and deletion. Use Dao.Unscoped() to get rid of them.
+func WithDefaultScope(data query.Data) DaoOption {
+	return func(opts *DaoOptions) {
+		opts.DefaultScopes = append(opts.DefaultScopes, data)
+	}
+}
+
+// WithScope registers a named scope which can be applied by Dao.Scope(name)
+func WithScope(name string, data query.Data) DaoOption {
+	return func(opts *DaoOptions) {
+		if opts.Scopes == nil {
+			opts.Scopes = make(map[string]query.Data)
+		}
+		opts.Scopes[name] = data
+	}
+}
+
+// WithCache caches results of selections out of transaction in c.
+//	Results of the table are invalidated on writing through the dao.
+func WithCache(c cache.Cache) DaoOption {
+	return func(opts *DaoOptions) {
+		opts.Cache = c
+	}
+}
+
+// WithStmtCache prepares statements and keeps at most size of them for reusing.
+//	Statements are closed by Dao.Close().
+func WithStmtCache(size int) DaoOption {
+	return func(opts *DaoOptions) {
+		opts.StmtCacheSize = size
+	}
+}
+
+// WithVerify verifies the model against the live table when creating dao
+//	and panics if they're mismatched.
+func WithVerify() DaoOption {
+	return func(opts *DaoOptions) {
+		opts.Verify = true
+	}
+}
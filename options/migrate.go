@@ -0,0 +1,37 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package options
+
+type MigrateOptions struct {
+	DryRun           bool
+	AllowDestructive bool
+	AllowTypeChanges bool
+}
+
+type MigrateOption func(opts *MigrateOptions)
+
+// WithDryRun only plans changes without applying them
+func WithDryRun() MigrateOption {
+	return func(opts *MigrateOptions) {
+		opts.DryRun = true
+	}
+}
+
+// WithDestructive allows applying changes which may lose data,
+//	e.g. dropping columns or modifying types.
+func WithDestructive() MigrateOption {
+	return func(opts *MigrateOptions) {
+		opts.AllowDestructive = true
+	}
+}
+
+// WithTypeChanges allows modifying types of columns which are not destructive,
+//	e.g. widening int to bigint, which may rebuild the table.
+//	It's implied by WithDestructive().
+func WithTypeChanges() MigrateOption {
+	return func(opts *MigrateOptions) {
+		opts.AllowTypeChanges = true
+	}
+}
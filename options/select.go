@@ -23,7 +23,9 @@ type SelectField struct {
 }
 
 type SelectOptions struct {
-	Fields []string
+	Fields   []string
+	Primary  bool
+	Distinct bool
 }
 
 type SelectOption func(opts *SelectOptions)
@@ -65,6 +67,21 @@ func WithFields(names ...string) SelectOption {
 	}
 }
 
+// WithPrimary forces reading from primary rather than replicas to read your writes
+func WithPrimary() SelectOption {
+	return func(opts *SelectOptions) {
+		opts.Primary = true
+	}
+}
+
+// WithDistinct removes duplicated rows by `select distinct`.
+//	It takes effect in Pluck, SelectMaps and SelectInto.
+func WithDistinct() SelectOption {
+	return func(opts *SelectOptions) {
+		opts.Distinct = true
+	}
+}
+
 func getField(
 	str string,
 	byName map[string]*types.ModelField,
@@ -136,3 +153,34 @@ func GenerateSelectFields(
 	sql = sqlBuilder.String()
 	return
 }
+
+// GenerateSelectExprs is like GenerateSelectFields but aliases of expressions are free
+//	rather than existed field names, e.g. "avg(@Cnt@) as avg_cnt".
+//	Aliases of result columns are returned in order.
+func GenerateSelectExprs(
+	names []string,
+	byName map[string]*types.ModelField,
+	byColumn map[string]*types.ModelField,
+) (sql string, aliases []string) {
+	sqlBuilder := strings.Builder{}
+	for i, str := range names {
+		var expr, alias string
+		if f := getField(str, byName, byColumn); f != nil {
+			expr, alias = "`"+f.Column+"`", f.Name
+		} else if arr := expressionPattern.FindStringSubmatch(str); len(arr) == 3 {
+			expr, alias = query.ParseColumnPlaceholder(arr[1], byName, byColumn), arr[2]
+		} else {
+			logrus.Panic("Incorrect field: ", str)
+		}
+		aliases = append(aliases, alias)
+		if i > 0 {
+			sqlBuilder.WriteString(", ")
+		}
+		sqlBuilder.WriteString(expr)
+		sqlBuilder.WriteString(" as `")
+		sqlBuilder.WriteString(alias)
+		sqlBuilder.WriteString("`")
+	}
+	sql = sqlBuilder.String()
+	return
+}
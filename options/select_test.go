@@ -72,3 +72,19 @@ func TestParseSelectField(t *testing.T) {
 	field = ParseSelectField("concat('id-', @Id@)", byName, byColumn)
 	assert.Nil(t, field)
 }
+
+func TestGenerateSelectExprs(t *testing.T) {
+	fields := model.Parse(TestSelectTable{})
+	byName := make(map[string]*types.ModelField, len(fields))
+	byColumn := make(map[string]*types.ModelField, len(fields))
+	for _, f := range fields {
+		byName[f.Name] = f
+		byColumn[f.Column] = f
+	}
+
+	sql, aliases := GenerateSelectExprs([]string{"Name", "created", "avg(@Created@) as avg_created"}, byName, byColumn)
+	assert.Equal(t, "`name` as `Name`, `created` as `Created`, avg(`created`) as `avg_created`", sql)
+	assert.Equal(t, []string{"Name", "Created", "avg_created"}, aliases)
+
+	assert.Panics(t, func() { GenerateSelectExprs([]string{"avg(@Created@)"}, byName, byColumn) })
+}
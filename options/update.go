@@ -5,6 +5,7 @@
 package options
 
 import (
+	"reflect"
 	"strings"
 
 	"github.com/jasonjoo2010/godao/query"
@@ -12,12 +13,17 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// UpdateSQL generates the statement updating all non-primary fields by primaries.
+//	The tenant field, if any, is taken into condition as primaries.
+//	The version field, if any, is increased and checked in condition.
+//	Arguments should be ordered as: non-primary fields, primaries(including tenant), version.
 func UpdateSQL(table string, fields []*types.ModelField) string {
 	b := strings.Builder{}
 	b1 := strings.Builder{} // primary condition
 	b2 := strings.Builder{} // fields
+	var version *types.ModelField
 	for _, f := range fields {
-		if f.Primary {
+		if f.Primary || f.Tenant {
 			if b1.Len() > 0 {
 				b1.WriteString(" and ")
 			}
@@ -28,10 +34,23 @@ func UpdateSQL(table string, fields []*types.ModelField) string {
 			if b2.Len() > 0 {
 				b2.WriteString(", ")
 			}
+			b2.WriteString("`")
 			b2.WriteString(f.Column)
-			b2.WriteString(" = ?")
+			if f.Version {
+				version = f
+				b2.WriteString("` = `")
+				b2.WriteString(f.Column)
+				b2.WriteString("` + 1")
+			} else {
+				b2.WriteString("` = ?")
+			}
 		}
 	}
+	if version != nil {
+		b1.WriteString(" and `")
+		b1.WriteString(version.Column)
+		b1.WriteString("` = ?")
+	}
 	b.WriteString("update ")
 	b.WriteString("`")
 	b.WriteString(table)
@@ -59,9 +78,15 @@ func UpdateEntrySQL(
 		b.WriteString("`")
 		b.WriteString(f.Column)
 		b.WriteString("` = ")
-		if entry.Value != nil {
+		if entry.Null {
+			b.WriteString("null")
+		} else if entry.Value != nil {
 			b.WriteString("?")
-			args = append(args, entry.Value)
+			if f.NullZero && reflect.ValueOf(entry.Value).IsZero() {
+				args = append(args, nil)
+			} else {
+				args = append(args, entry.Value)
+			}
 		} else if entry.Expr != "" {
 			b.WriteString(query.ParseColumnPlaceholder(entry.Expr, byName, byColumn))
 			if len(entry.Args) > 0 {
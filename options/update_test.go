@@ -0,0 +1,70 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package options
+
+import (
+	"testing"
+
+	"github.com/jasonjoo2010/godao/model"
+	"github.com/jasonjoo2010/godao/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestUpdateTable struct {
+	Id      int64 `dao:"primary"`
+	Name    string
+	Created int64
+}
+
+type TestUpdateVersionTable struct {
+	Id   int64 `dao:"primary"`
+	Name string
+	Ver  int64 `dao:"version"`
+}
+
+type TestUpdateTenantTable struct {
+	Id     int64 `dao:"primary"`
+	Tenant int64 `dao:"tenant"`
+	Name   string
+}
+
+func TestUpdateSQL(t *testing.T) {
+	sql := UpdateSQL("t", model.Parse(TestUpdateTable{}))
+	assert.Equal(t, "update `t` set `name` = ?, `created` = ? where `id` = ?", sql)
+
+	sql = UpdateSQL("t", model.Parse(TestUpdateVersionTable{}))
+	assert.Equal(t, "update `t` set `name` = ?, `ver` = `ver` + 1 where `id` = ? and `ver` = ?", sql)
+
+	sql = UpdateSQL("t", model.Parse(TestUpdateTenantTable{}))
+	assert.Equal(t, "update `t` set `name` = ? where `id` = ? and `tenant` = ?", sql)
+}
+
+type TestUpdateNullTable struct {
+	Id      int64 `dao:"primary"`
+	Name    *string
+	Created int64 `dao:"nullzero"`
+}
+
+func TestUpdateEntrySQL(t *testing.T) {
+	fields := model.Parse(TestUpdateNullTable{})
+	byName := make(map[string]*types.ModelField)
+	byColumn := make(map[string]*types.ModelField)
+	for _, f := range fields {
+		byName[f.Name] = f
+		byColumn[f.Column] = f
+	}
+	sql, args := UpdateEntrySQL([]*types.UpdateEntry{
+		types.NewNull("Name"),
+		{Field: "Created", Value: int64(0)},
+	}, byName, byColumn)
+	assert.Equal(t, "`name` = null, `created` = ?", sql)
+	assert.Equal(t, []interface{}{nil}, args)
+
+	sql, args = UpdateEntrySQL([]*types.UpdateEntry{
+		{Field: "Created", Value: int64(3)},
+	}, byName, byColumn)
+	assert.Equal(t, "`created` = ?", sql)
+	assert.Equal(t, []interface{}{int64(3)}, args)
+}
@@ -0,0 +1,29 @@
+module github.com/jasonjoo2010/godao/otelgodao
+
+go 1.22
+
+require (
+	github.com/DATA-DOG/go-sqlmock v1.5.0
+	github.com/jasonjoo2010/godao v0.0.0-20261018162808-2f9664570161
+	github.com/stretchr/testify v1.8.4
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/sdk v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/jasonjoo2010/enhanced-utils v0.0.0-20200603160505-ca106040678a // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.3 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/sirupsen/logrus v1.6.0 // indirect
+	go.opentelemetry.io/otel/metric v1.24.0 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// Builds against the working tree for local development. It is ignored when
+// this module is required by others, which resolve the version above.
+replace github.com/jasonjoo2010/godao => ../
@@ -0,0 +1,139 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+// Package otelgodao provides OpenTelemetry tracing for godao.
+//	Every public method of dao invoked is traced by a span, and statements executed in it
+//	by child spans following the semantic conventions of database.
+package otelgodao
+
+import (
+	"context"
+
+	"github.com/jasonjoo2010/godao/options"
+	"github.com/jasonjoo2010/godao/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/jasonjoo2010/godao/otelgodao"
+)
+
+type config struct {
+	provider  trace.TracerProvider
+	system    string
+	sanitizer func(sql string) string
+}
+
+type Option func(cfg *config)
+
+// WithTracerProvider specifies the provider instead of the global one
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(cfg *config) {
+		cfg.provider = provider
+	}
+}
+
+// WithDBSystem specifies `db.system` attribute, default is "mysql"
+func WithDBSystem(system string) Option {
+	return func(cfg *config) {
+		cfg.system = system
+	}
+}
+
+// WithSanitizer processes the statement before recording it into `db.statement`.
+//	Arguments are never recorded but literals may exist in expressions.
+func WithSanitizer(fn func(sql string) string) Option {
+	return func(cfg *config) {
+		cfg.sanitizer = fn
+	}
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{
+		system: "mysql",
+	}
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	if cfg.provider == nil {
+		cfg.provider = otel.GetTracerProvider()
+	}
+	return cfg
+}
+
+// WithTracing traces every public method of dao invoked by a span
+//	and statements executed in it by child spans:
+//	godao.NewDao(Demo{}, db, otelgodao.WithTracing())
+func WithTracing(opts ...Option) options.DaoOption {
+	cfg := newConfig(opts)
+	return func(o *options.DaoOptions) {
+		options.WithCallObservers(newCallObserver(cfg))(o)
+		options.WithInterceptors(newInterceptor(cfg))(o)
+	}
+}
+
+// NewCallObserver creates an observer opening a span for every public method of dao invoked
+func NewCallObserver(opts ...Option) types.CallObserver {
+	return newCallObserver(newConfig(opts))
+}
+
+func newCallObserver(cfg *config) types.CallObserver {
+	tracer := cfg.provider.Tracer(instrumentationName)
+	return func(ctx context.Context, call *types.Call) (context.Context, func(err error)) {
+		ctx, span := tracer.Start(ctx, "godao."+call.Method,
+			trace.WithSpanKind(trace.SpanKindInternal),
+			trace.WithAttributes(
+				attribute.String("db.system", cfg.system),
+				attribute.String("db.sql.table", call.Table),
+				attribute.String("code.function", call.Method),
+			),
+		)
+		return ctx, func(err error) {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}
+	}
+}
+
+// NewInterceptor creates an interceptor opening a span for every statement executed.
+//	They are children of spans of calls if NewCallObserver is registered too.
+func NewInterceptor(opts ...Option) types.Interceptor {
+	return newInterceptor(newConfig(opts))
+}
+
+func newInterceptor(cfg *config) types.Interceptor {
+	tracer := cfg.provider.Tracer(instrumentationName)
+	return func(ctx context.Context, stmt *types.Statement, next types.Invoker) (*types.Result, error) {
+		statement := stmt.SQL
+		if cfg.sanitizer != nil {
+			statement = cfg.sanitizer(statement)
+		}
+		ctx, span := tracer.Start(ctx, "godao."+stmt.Operation.String(),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", cfg.system),
+				attribute.String("db.sql.table", stmt.Table),
+				attribute.String("db.operation", stmt.Operation.String()),
+				attribute.String("db.statement", statement),
+			),
+		)
+		defer span.End()
+
+		result, err := next(ctx, stmt)
+		if result != nil {
+			span.SetAttributes(attribute.Int64("db.rows_affected", result.RowsAffected))
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return result, err
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package otelgodao
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jasonjoo2010/godao"
+	"github.com/jasonjoo2010/godao/options"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type Demo struct {
+	Id   int64 `dao:"primary;auto_increment"`
+	Name string
+}
+
+func TestTracing(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+	defer db.Close()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	dao := godao.NewDao(Demo{}, db, options.WithInterceptors(NewInterceptor(WithTracerProvider(provider))))
+
+	mock.ExpectQuery("select count(*) from `demo` where `name` = ?").
+		WithArgs("n1").
+		WillReturnRows(sqlmock.NewRows([]string{"cnt"}).AddRow(2))
+	mock.ExpectExec("delete from `demo` where `id` = ?").
+		WithArgs(1).
+		WillReturnError(errors.New("failed"))
+
+	cnt, err := dao.CountBy(context.Background(), "Name", "n1")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), cnt)
+	_, err = dao.Delete(context.Background(), 1)
+	assert.NotNil(t, err)
+
+	spans := exporter.GetSpans()
+	assert.Equal(t, 2, len(spans))
+
+	span := spans[0]
+	assert.Equal(t, "godao.aggregate", span.Name)
+	assert.Contains(t, span.Attributes, attribute.String("db.system", "mysql"))
+	assert.Contains(t, span.Attributes, attribute.String("db.sql.table", "demo"))
+	assert.Contains(t, span.Attributes, attribute.String("db.operation", "aggregate"))
+	assert.Contains(t, span.Attributes, attribute.String("db.statement", "select count(*) from `demo` where `name` = ?"))
+	assert.Contains(t, span.Attributes, attribute.Int64("db.rows_affected", 1))
+	assert.Equal(t, codes.Unset, span.Status.Code)
+
+	span = spans[1]
+	assert.Equal(t, "godao.delete", span.Name)
+	assert.Equal(t, codes.Error, span.Status.Code)
+	assert.Equal(t, 1, len(span.Events))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestTracingCalls(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+	defer db.Close()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	dao := godao.NewDao(Demo{}, db, WithTracing(WithTracerProvider(provider)))
+
+	updateSQL := "update `demo` set `name` = ? where `id` = ?"
+	mock.ExpectBegin()
+	mock.ExpectExec(updateSQL).WithArgs("a", int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(updateSQL).WithArgs("b", int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	affected, err := dao.BatchUpdate(context.Background(), []interface{}{&Demo{1, "a"}, &Demo{2, "b"}})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), affected)
+
+	// nested calls are traced once
+	mock.ExpectQuery("select `id` as `Id`, `name` as `Name` from `demo` where `id` = ? limit 0, 1;").
+		WithArgs(1).
+		WillReturnError(errors.New("failed"))
+	_, err = dao.SelectOne(context.Background(), 1)
+	assert.NotNil(t, err)
+
+	spans := exporter.GetSpans()
+	assert.Equal(t, 5, len(spans))
+	// ended in order: children first
+	assert.Equal(t, "godao.update", spans[0].Name)
+	assert.Equal(t, "godao.update", spans[1].Name)
+	call := spans[2]
+	assert.Equal(t, "godao.BatchUpdate", call.Name)
+	assert.Contains(t, call.Attributes, attribute.String("db.sql.table", "demo"))
+	assert.Equal(t, call.SpanContext.SpanID(), spans[0].Parent.SpanID())
+	assert.Equal(t, call.SpanContext.SpanID(), spans[1].Parent.SpanID())
+	assert.False(t, call.Parent.IsValid())
+
+	assert.Equal(t, "godao.select", spans[3].Name)
+	call = spans[4]
+	assert.Equal(t, "godao.SelectOne", call.Name)
+	assert.Equal(t, call.SpanContext.SpanID(), spans[3].Parent.SpanID())
+	assert.Equal(t, codes.Error, call.Status.Code)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,101 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+// Package promgodao provides a metrics collector of godao based on Prometheus.
+//	Latency histogram, error and row counters of statements are exported,
+//	labelled by table and operation.
+package promgodao
+
+import (
+	"time"
+
+	"github.com/jasonjoo2010/godao/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	namespace string
+	buckets   []float64
+}
+
+type Option func(cfg *config)
+
+// WithNamespace specifies the namespace of metrics, default is "godao"
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithBuckets specifies the buckets of latency histogram in seconds
+func WithBuckets(buckets []float64) Option {
+	return func(cfg *config) {
+		cfg.buckets = buckets
+	}
+}
+
+// Collector implements types.MetricsCollector and prometheus.Collector.
+//	It should be registered into prometheus and passed to dao:
+//		collector := promgodao.NewCollector()
+//		prometheus.MustRegister(collector)
+//		dao := godao.NewDao(Demo{}, db, options.WithMetrics(collector))
+//	Collector can be shared among daos.
+type Collector struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+	rows     *prometheus.CounterVec
+}
+
+// NewCollector creates a collector with labels `table` and `operation`
+func NewCollector(opts ...Option) *Collector {
+	cfg := &config{
+		namespace: "godao",
+		buckets:   prometheus.DefBuckets,
+	}
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	labels := []string{"table", "operation"}
+	return &Collector{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: cfg.namespace,
+			Name:      "statement_duration_seconds",
+			Help:      "Latency of statements executed.",
+			Buckets:   cfg.buckets,
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.namespace,
+			Name:      "statement_errors_total",
+			Help:      "Count of statements failed.",
+		}, labels),
+		rows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.namespace,
+			Name:      "statement_rows_total",
+			Help:      "Rows affected by writing or fetched by reading.",
+		}, labels),
+	}
+}
+
+func (c *Collector) Observe(op types.Operation, table string, duration time.Duration, rows int64, err error) {
+	operation := op.String()
+	c.duration.WithLabelValues(table, operation).Observe(duration.Seconds())
+	if err != nil {
+		c.errors.WithLabelValues(table, operation).Inc()
+	}
+	if rows > 0 {
+		c.rows.WithLabelValues(table, operation).Add(float64(rows))
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.duration.Describe(ch)
+	c.errors.Describe(ch)
+	c.rows.Describe(ch)
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.duration.Collect(ch)
+	c.errors.Collect(ch)
+	c.rows.Collect(ch)
+}
@@ -0,0 +1,31 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package promgodao
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jasonjoo2010/godao/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	collector := NewCollector()
+	registry := prometheus.NewRegistry()
+	assert.Nil(t, registry.Register(collector))
+
+	collector.Observe(types.OperationSelect, "demo", 10*time.Millisecond, 3, nil)
+	collector.Observe(types.OperationSelect, "demo", 20*time.Millisecond, 2, nil)
+	collector.Observe(types.OperationInsert, "demo", time.Millisecond, 0, errors.New("failed"))
+
+	assert.Equal(t, float64(5), testutil.ToFloat64(collector.rows.WithLabelValues("demo", "select")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(collector.errors.WithLabelValues("demo", "insert")))
+	assert.Equal(t, 2, testutil.CollectAndCount(collector.duration))
+	assert.Equal(t, 4, testutil.CollectAndCount(collector))
+}
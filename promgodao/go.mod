@@ -0,0 +1,27 @@
+module github.com/jasonjoo2010/godao/promgodao
+
+go 1.22
+
+require (
+	github.com/jasonjoo2010/godao v0.0.0-20261018162808-2f9664570161
+	github.com/prometheus/client_golang v1.19.0
+	github.com/stretchr/testify v1.8.4
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/prometheus/common v0.48.0 // indirect
+	github.com/prometheus/procfs v0.12.0 // indirect
+	golang.org/x/sys v0.16.0 // indirect
+	google.golang.org/protobuf v1.32.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// Builds against the working tree for local development. It is ignored when
+// this module is required by others, which resolve the version above.
+replace github.com/jasonjoo2010/godao => ../
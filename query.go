@@ -115,6 +115,12 @@ func (q *Query) NoLimit() *Query {
 	return q
 }
 
+// Where adds conditions built by typed columns, e.g. DemoCols.Name.Eq("x")
+func (q *Query) Where(conditions ...query.Condition) *Query {
+	q.conditions = append(q.conditions, conditions...)
+	return q
+}
+
 // And joins all conditions in this query by AND
 func (q *Query) And() *Query {
 	q.logicalOr = false
@@ -149,6 +155,12 @@ func (q *Query) OrderBy(field_name string, desc bool) *Query {
 	return q
 }
 
+// Sort takes orders built by typed columns, e.g. DemoCols.Created.Desc()
+func (q *Query) Sort(orders ...query.Order) *Query {
+	q.order_by = append(q.order_by, orders...)
+	return q
+}
+
 // Page uses the pagination style to locate offset and limit
 func (q *Query) Page(page, page_size int) *Query {
 	if page < 1 || page_size < 1 {
@@ -0,0 +1,97 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package query
+
+// Column refers to a field of model by its name.
+//	Columns are generated by godao-cols to make queries refactor-safe.
+type Column string
+
+// Field returns the name of field
+func (c Column) Field() string {
+	return string(c)
+}
+
+func (c Column) condition(op Op, val interface{}) Condition {
+	return Condition{
+		Field: string(c),
+		Op:    op,
+		Value: val,
+	}
+}
+
+// Eq represents a "=" condition
+func (c Column) Eq(val interface{}) Condition {
+	return c.condition(OpEqual, val)
+}
+
+// Ne represents a "<>" condition
+func (c Column) Ne(val interface{}) Condition {
+	return c.condition(OpNotEqual, val)
+}
+
+// Lt represents a "<" condition
+func (c Column) Lt(val interface{}) Condition {
+	return c.condition(OpLess, val)
+}
+
+// Le represents a "<=" condition
+func (c Column) Le(val interface{}) Condition {
+	return c.condition(OpLessOrEqual, val)
+}
+
+// Gt represents a ">" condition
+func (c Column) Gt(val interface{}) Condition {
+	return c.condition(OpGreater, val)
+}
+
+// Ge represents a ">=" condition
+func (c Column) Ge(val interface{}) Condition {
+	return c.condition(OpGreaterOrEqual, val)
+}
+
+// Like represents a "like %val%" condition
+func (c Column) Like(val string) Condition {
+	return c.condition(OpLike, val)
+}
+
+// StartsWith represents a "like val%" condition
+func (c Column) StartsWith(val string) Condition {
+	return c.condition(OpStartsWith, val)
+}
+
+// EndsWith represents a "like %val" condition
+func (c Column) EndsWith(val string) Condition {
+	return c.condition(OpEndsWith, val)
+}
+
+// IsNil represents a "is null" condition
+func (c Column) IsNil() Condition {
+	return c.condition(OpNil, nil)
+}
+
+// NotNil represents a "not null" condition
+func (c Column) NotNil() Condition {
+	return c.condition(OpNotNil, nil)
+}
+
+// In represents a "in" condition
+func (c Column) In(vals ...interface{}) Condition {
+	return c.condition(OpIn, vals)
+}
+
+// NotIn represents a "not in" condition
+func (c Column) NotIn(vals ...interface{}) Condition {
+	return c.condition(OpNotIn, vals)
+}
+
+// Asc orders by the column ascending
+func (c Column) Asc() Order {
+	return Order{Field: string(c)}
+}
+
+// Desc orders by the column descending
+func (c Column) Desc() Order {
+	return Order{Field: string(c), Desc: true}
+}
@@ -6,6 +6,7 @@ package query
 
 import (
 	"fmt"
+	"reflect"
 	"regexp"
 	"strings"
 
@@ -69,6 +70,20 @@ func (o Op) Op() string {
 	return ""
 }
 
+// Pattern returns the pattern bound for val by `like` operators, e.g. "%val" for OpEndsWith.
+//	val is returned as it is for other operators.
+func (o Op) Pattern(val string) string {
+	switch o {
+	case OpLike:
+		return "%" + val + "%"
+	case OpStartsWith:
+		return val + "%"
+	case OpEndsWith:
+		return "%" + val
+	}
+	return val
+}
+
 type Data struct {
 	Conditions    []Condition
 	Children      []Data
@@ -149,14 +164,7 @@ func generateCondition(c *Condition,
 			if !ok {
 				panic("`like` / `startsWith` / `endsWith` should take a string as argument")
 			}
-			switch c.Op {
-			case OpStartsWith:
-				return prefix + " ?", []interface{}{val + "%"}
-			case OpEndsWith:
-				return prefix + " ?", []interface{}{"%" + val}
-			default:
-				return prefix + " ?", []interface{}{"%" + val + "%"}
-			}
+			return prefix + " ?", []interface{}{c.Op.Pattern(val)}
 		default:
 			return prefix + " ?", []interface{}{c.Value}
 		}
@@ -262,3 +270,42 @@ func ConditionSQL(
 
 	return sql.String(), args
 }
+
+// ArgOps returns operators of the arguments generated by ConditionSQL in the same order.
+//	Arguments of `in` / `not in` share the operator, and OpExpr is returned for arguments of expressions.
+func ArgOps(data *Data) []Op {
+	var ops []Op
+	for _, c := range data.Conditions {
+		switch c.Op {
+		case OpNil, OpNotNil:
+		case OpExpr:
+			for range c.Args {
+				ops = append(ops, OpExpr)
+			}
+		case OpIn, OpNotIn:
+			arr, _ := c.Value.([]interface{})
+			for range arr {
+				ops = append(ops, c.Op)
+			}
+		default:
+			ops = append(ops, c.Op)
+		}
+	}
+	for i := range data.Children {
+		ops = append(ops, ArgOps(&data.Children[i])...)
+	}
+	return ops
+}
+
+// Values converts a typed slice, e.g. []int64 plucked, into arguments of `in` / `not in`
+func Values(slice interface{}) []interface{} {
+	val := reflect.ValueOf(slice)
+	if val.Kind() != reflect.Slice {
+		panic("Values should take a slice as argument")
+	}
+	arr := make([]interface{}, val.Len())
+	for i := range arr {
+		arr[i] = val.Index(i).Interface()
+	}
+	return arr
+}
@@ -153,3 +153,30 @@ func TestConditionOr(t *testing.T) {
 	fmt.Println(sql)
 	fmt.Println(args)
 }
+
+func TestValues(t *testing.T) {
+	assert.Equal(t, []interface{}{int64(1), int64(2)}, Values([]int64{1, 2}))
+	assert.Equal(t, []interface{}{}, Values([]string{}))
+	assert.Panics(t, func() { Values(1) })
+}
+
+func TestArgOps(t *testing.T) {
+	data := &Data{
+		Conditions: []Condition{
+			{Op: OpStartsWith, Field: "Name", Value: "a"},
+			{Op: OpNil, Field: "Password"},
+			{Op: OpIn, Field: "Id", Value: []interface{}{1, 2}},
+		},
+		Children: []Data{{
+			Conditions: []Condition{
+				{Op: OpExpr, Field: "@LastLogin@", Value: "> ?", Args: []interface{}{3}},
+				{Op: OpEndsWith, Field: "Name", Value: "b"},
+			},
+		}},
+	}
+	assert.Equal(t, []Op{OpStartsWith, OpIn, OpIn, OpExpr, OpEndsWith}, ArgOps(data))
+	assert.Equal(t, "a%", OpStartsWith.Pattern("a"))
+	assert.Equal(t, "%a", OpEndsWith.Pattern("a"))
+	assert.Equal(t, "%a%", OpLike.Pattern("a"))
+	assert.Equal(t, "a", OpEqual.Pattern("a"))
+}
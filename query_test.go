@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/jasonjoo2010/godao/query"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -113,3 +114,17 @@ func TestQueryCondition(t *testing.T) {
 	data := q.Data()
 	assert.Equal(t, 3, len(data.Conditions))
 }
+
+func TestQueryColumn(t *testing.T) {
+	name, created := query.Column("Name"), query.Column("Created")
+	q := Query{}
+
+	q.Where(name.Eq("x"), created.Ge(100), name.In("a", "b")).Sort(created.Desc(), name.Asc())
+	data := q.Data()
+	assert.Equal(t, []query.Condition{
+		{Field: "Name", Op: query.OpEqual, Value: "x"},
+		{Field: "Created", Op: query.OpGreaterOrEqual, Value: 100},
+		{Field: "Name", Op: query.OpIn, Value: []interface{}{"a", "b"}},
+	}, data.Conditions)
+	assert.Equal(t, []query.Order{{Field: "Created", Desc: true}, {Field: "Name"}}, data.Order)
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+
+	"github.com/jasonjoo2010/godao/query"
+	"github.com/jasonjoo2010/godao/schema"
+	"github.com/jasonjoo2010/godao/types"
+)
+
+// CreateTableSQL generates the statement creating the table of dao in MySQL.
+//	Column types are derived from types of fields, and can be tuned by tags
+//	`size=`, `nullable`, `default=`, `index=name` and `unique=name`.
+func (dao *Dao) CreateTableSQL() (string, error) {
+	return schema.CreateTableSQL(dao.table, dao.fields)
+}
+
+// CreateTable creates the table of dao, or tables of all shards for a sharding dao
+func (dao *Dao) CreateTable(ctx context.Context) (err error) {
+	ctx, finish := dao.observe(ctx, "CreateTable")
+	defer finish(&err)
+	shards, err := dao.route(ctx, &query.Data{})
+	if err != nil {
+		return err
+	}
+	for _, shard := range shards {
+		sqlStr, err := schema.CreateTableSQL(shard.Table, dao.fields)
+		if err != nil {
+			return err
+		}
+		e, err := dao.shardExecutor(ctx, shard)
+		if err != nil {
+			return err
+		}
+		if _, err = dao.exec(ctx, e, types.OperationDDL, shard.Table, sqlStr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Verify checks the model against the live table, or tables of all shards for a sharding dao.
+//	A *schema.VerifyError is returned describing fields without column, NOT NULL columns
+//	without default missing in model, incompatible types and primary keys mismatched.
+func (dao *Dao) Verify(ctx context.Context) (err error) {
+	ctx, finish := dao.observe(ctx, "Verify")
+	defer finish(&err)
+	shards, err := dao.route(ctx, &query.Data{})
+	if err != nil {
+		return err
+	}
+	for _, shard := range shards {
+		e, err := dao.shardExecutor(ctx, shard)
+		if err != nil {
+			return err
+		}
+		live, err := schema.ReadTable(ctx, e, shard.Table)
+		if err != nil {
+			return err
+		}
+		if err = schema.Verify(shard.Table, dao.fields, live); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jasonjoo2010/godao/types"
+)
+
+var (
+	intWidthPattern = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|bigint)\(\d+\)`)
+)
+
+// Queryer is implemented by *sql.DB and *sql.Tx
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Column is a column defined in live table
+type Column struct {
+	Name     string
+	Type     string
+	Nullable bool
+	// Whether default value is defined, null is regarded as defined for nullable column
+	HasDefault    bool
+	Primary       bool
+	AutoIncrement bool
+}
+
+// Table is the definition of live table
+type Table struct {
+	Columns []Column
+	// Indexes except primary key
+	Indexes []Index
+}
+
+// Change is a statement altering live table towards the model.
+//	Destructive changes may lose data, e.g. dropping columns or narrowing types.
+type Change struct {
+	SQL         string
+	Destructive bool
+	// Whether the type of column is modified, which may rebuild the table even if it's widening
+	TypeChanged bool
+	// Whether it has been applied by migrator
+	Applied bool
+}
+
+// ReadTable reads the definition of table in current database from information_schema.
+//	nil is returned if the table doesn't exist. Only MySQL is supported,
+//	other dialects like PRAGMA table_info of SQLite are not.
+func ReadTable(ctx context.Context, q Queryer, table string) (*Table, error) {
+	rows, err := q.QueryContext(ctx, "select `column_name`, `column_type`, `is_nullable`, `column_default`, `column_key`, `extra` from `information_schema`.`columns` where `table_schema` = database() and `table_name` = ? order by `ordinal_position`", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	t := &Table{}
+	for rows.Next() {
+		var (
+			c                    Column
+			nullable, key, extra string
+			defaultValue         sql.NullString
+		)
+		if err = rows.Scan(&c.Name, &c.Type, &nullable, &defaultValue, &key, &extra); err != nil {
+			return nil, err
+		}
+		c.Nullable = nullable == "YES"
+		c.HasDefault = defaultValue.Valid || c.Nullable
+		c.Primary = key == "PRI"
+		c.AutoIncrement = strings.Contains(strings.ToLower(extra), "auto_increment")
+		t.Columns = append(t.Columns, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(t.Columns) == 0 {
+		return nil, nil
+	}
+
+	rows, err = q.QueryContext(ctx, "select `index_name`, `non_unique`, `column_name` from `information_schema`.`statistics` where `table_schema` = database() and `table_name` = ? and `index_name` <> 'PRIMARY' order by `index_name`, `seq_in_index`", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	pos := make(map[string]int)
+	for rows.Next() {
+		var (
+			name, column string
+			nonUnique    int
+		)
+		if err = rows.Scan(&name, &nonUnique, &column); err != nil {
+			return nil, err
+		}
+		i, ok := pos[name]
+		if !ok {
+			i = len(t.Indexes)
+			pos[name] = i
+			t.Indexes = append(t.Indexes, Index{Name: name, Unique: nonUnique == 0})
+		}
+		t.Indexes[i].Columns = append(t.Indexes[i].Columns, column)
+	}
+	return t, rows.Err()
+}
+
+// normalizeType strips display width of integers which is meaningless, e.g. bigint(20)
+func normalizeType(typ string) string {
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	if strings.HasPrefix(typ, "tinyint(1)") {
+		return typ
+	}
+	return intWidthPattern.ReplaceAllString(typ, "$1")
+}
+
+// Diff generates changes altering live table towards fields of model:
+//	missing columns, type or nullability changes, missing or changed indexes and extra columns.
+//	Widening a type, e.g. int => bigint or varchar(100) => varchar(255), or making a column nullable is safe,
+//	while narrowing types which can still be scanned into the field is skipped. Other modifications,
+//	including NOT NULL, are destructive. Modifications of types are marked by TypeChanged.
+//	Defaults are not compared.
+func Diff(table string, fields []*types.ModelField, live *Table) ([]Change, error) {
+	prefix := "alter table `" + table + "` "
+	if live == nil {
+		sqlStr, err := CreateTableSQL(table, fields)
+		if err != nil {
+			return nil, err
+		}
+		return []Change{{SQL: sqlStr}}, nil
+	}
+	var changes []Change
+	columns := make(map[string]Column, len(live.Columns))
+	for _, c := range live.Columns {
+		columns[strings.ToLower(c.Name)] = c
+	}
+	modeled := make(map[string]bool, len(fields))
+	for i, f := range fields {
+		modeled[strings.ToLower(f.Column)] = true
+		definition, err := ColumnDefinition(f)
+		if err != nil {
+			return nil, err
+		}
+		c, ok := columns[strings.ToLower(f.Column)]
+		if !ok {
+			position := " first"
+			if i > 0 {
+				position = " after `" + fields[i-1].Column + "`"
+			}
+			changes = append(changes, Change{SQL: prefix + "add column " + definition + position})
+			continue
+		}
+		typ, _ := ColumnType(f)
+		liveType := normalizeType(c.Type)
+		nullable := Nullable(f)
+		typeChanged := liveType != typ
+		if typeChanged && !widens(liveType, typ) && compatible(f, c) {
+			// keep the live type rather than narrowing it
+			typeChanged = false
+			if definition, err = columnDefinition(f, liveType); err != nil {
+				return nil, err
+			}
+		}
+		if !typeChanged && c.Nullable == nullable {
+			continue
+		}
+		changes = append(changes, Change{
+			SQL:         prefix + "modify column " + definition,
+			Destructive: (typeChanged && !widens(liveType, typ)) || (c.Nullable && !nullable),
+			TypeChanged: typeChanged,
+		})
+	}
+	indexes := make(map[string]Index, len(live.Indexes))
+	for _, index := range live.Indexes {
+		indexes[strings.ToLower(index.Name)] = index
+	}
+	for _, index := range Indexes(fields) {
+		existed, ok := indexes[strings.ToLower(index.Name)]
+		if !ok {
+			changes = append(changes, Change{SQL: prefix + "add " + IndexDefinition(index)})
+			continue
+		}
+		if existed.Unique != index.Unique || !sameColumns(existed.Columns, index.Columns) {
+			changes = append(changes, Change{
+				SQL:         prefix + "drop key `" + existed.Name + "`, add " + IndexDefinition(index),
+				Destructive: true,
+			})
+		}
+	}
+	for _, c := range live.Columns {
+		if !modeled[strings.ToLower(c.Name)] {
+			changes = append(changes, Change{
+				SQL:         prefix + "drop column `" + c.Name + "`",
+				Destructive: true,
+			})
+		}
+	}
+	return changes, nil
+}
+
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	integerRanks = map[string]int{"tinyint": 1, "smallint": 2, "mediumint": 3, "int": 4, "integer": 4, "bigint": 5}
+	// capacities of string and binary types without length
+	lobSizes = map[string]int64{
+		"tinytext": 255, "text": 65535, "mediumtext": 16777215, "longtext": 4294967295,
+		"tinyblob": 255, "blob": 65535, "mediumblob": 16777215, "longblob": 4294967295,
+	}
+)
+
+// parseType splits normalized column type into base name, arguments and whether it's unsigned,
+//	e.g. "decimal(10,2) unsigned" => decimal, [10 2], true
+func parseType(typ string) (base string, args []int64, unsigned bool) {
+	unsigned = strings.Contains(typ, "unsigned")
+	base = typ
+	if i := strings.IndexAny(base, "( "); i > 0 {
+		base = base[:i]
+	}
+	if i, j := strings.IndexByte(typ, '('), strings.IndexByte(typ, ')'); i > 0 && j > i {
+		for _, s := range strings.Split(typ[i+1:j], ",") {
+			n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				return base, nil, unsigned
+			}
+			args = append(args, n)
+		}
+	}
+	return
+}
+
+// capacity returns the max length of string or binary type
+func capacity(base string, args []int64) (int64, bool) {
+	switch base {
+	case "char", "varchar", "binary", "varbinary":
+		if len(args) == 1 {
+			return args[0], true
+		}
+		if base == "char" || base == "binary" {
+			return 1, true
+		}
+		return 0, false
+	}
+	size, ok := lobSizes[base]
+	return size, ok
+}
+
+// widens returns whether altering column from type to type keeps all values
+func widens(from, to string) bool {
+	if from == to {
+		return false
+	}
+	fromBase, fromArgs, fromUnsigned := parseType(from)
+	toBase, toArgs, toUnsigned := parseType(to)
+	if columnCategory(fromBase) != columnCategory(toBase) {
+		return false
+	}
+	switch columnCategory(fromBase) {
+	case categoryInteger:
+		fromRank, ok1 := integerRanks[fromBase]
+		toRank, ok2 := integerRanks[toBase]
+		if !ok1 || !ok2 || toRank <= fromRank {
+			return false
+		}
+		// unsigned fits in larger signed one, but signed never fits in unsigned
+		return fromUnsigned || !toUnsigned
+	case categoryDecimal:
+		p1, s1 := decimalArgs(fromArgs)
+		p2, s2 := decimalArgs(toArgs)
+		return s2 >= s1 && p2-s2 >= p1-s1 && (fromUnsigned || !toUnsigned)
+	case categoryFloat:
+		return fromBase == "float" && toBase == "double" && (fromUnsigned || !toUnsigned)
+	case categoryString, categoryBinary:
+		if toBase == "char" || toBase == "binary" {
+			// values are padded
+			if toBase != fromBase {
+				return false
+			}
+		}
+		fromSize, ok1 := capacity(fromBase, fromArgs)
+		toSize, ok2 := capacity(toBase, toArgs)
+		return ok1 && ok2 && toSize > fromSize
+	case categoryTime:
+		return fromBase == "date" && (toBase == "datetime" || toBase == "timestamp")
+	}
+	return false
+}
+
+// decimalArgs returns precision and scale of decimal, default is decimal(10,0)
+func decimalArgs(args []int64) (int64, int64) {
+	switch len(args) {
+	case 0:
+		return 10, 0
+	case 1:
+		return args[0], 0
+	}
+	return args[0], args[1]
+}
@@ -0,0 +1,105 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/jasonjoo2010/godao/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type Profile struct {
+	Id       int64  `dao:"primary;auto_increment"`
+	Email    string `dao:"size=100;unique=uk_email"`
+	Nickname string `dao:"size=50;index=idx_name"`
+	Age      int
+}
+
+func TestDiff(t *testing.T) {
+	fields := model.Parse(Profile{})
+
+	changes, err := Diff("profile", fields, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(changes))
+	assert.Contains(t, changes[0].SQL, "create table `profile`")
+
+	changes, err = Diff("profile", fields, &Table{
+		Columns: []Column{
+			{Name: "id", Type: "bigint(20)"},
+			{Name: "email", Type: "varchar(100)"},
+			{Name: "nickname", Type: "varchar(32)"},
+			{Name: "legacy", Type: "int(11)", Nullable: true},
+		},
+		Indexes: []Index{
+			{Name: "uk_email", Columns: []string{"email"}},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []Change{
+		{SQL: "alter table `profile` modify column `nickname` varchar(50) not null", TypeChanged: true},
+		{SQL: "alter table `profile` add column `age` bigint not null after `nickname`"},
+		{SQL: "alter table `profile` drop key `uk_email`, add unique key `uk_email` (`email`)", Destructive: true},
+		{SQL: "alter table `profile` add key `idx_name` (`nickname`)"},
+		{SQL: "alter table `profile` drop column `legacy`", Destructive: true},
+	}, changes)
+
+	// up to date
+	changes, err = Diff("profile", fields, &Table{
+		Columns: []Column{
+			{Name: "id", Type: "bigint(20)"},
+			{Name: "email", Type: "varchar(100)"},
+			{Name: "nickname", Type: "varchar(50)"},
+			{Name: "age", Type: "bigint"},
+		},
+		Indexes: []Index{
+			{Name: "idx_name", Columns: []string{"nickname"}},
+			{Name: "uk_email", Unique: true, Columns: []string{"email"}},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestDiffTypes(t *testing.T) {
+	fields := model.Parse(Profile{})
+	changes, err := Diff("profile", fields, &Table{
+		Columns: []Column{
+			{Name: "id", Type: "int(11)"},
+			// narrowing but compatible
+			{Name: "email", Type: "text", Nullable: true},
+			{Name: "nickname", Type: "varchar(255)"},
+			// category changed
+			{Name: "age", Type: "varchar(10)"},
+		},
+		Indexes: []Index{
+			{Name: "idx_name", Columns: []string{"nickname"}},
+			{Name: "uk_email", Unique: true, Columns: []string{"email"}},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []Change{
+		{SQL: "alter table `profile` modify column `id` bigint not null auto_increment", TypeChanged: true},
+		{SQL: "alter table `profile` modify column `email` text not null", Destructive: true},
+		{SQL: "alter table `profile` modify column `age` bigint not null", Destructive: true, TypeChanged: true},
+	}, changes)
+}
+
+func TestWidens(t *testing.T) {
+	assert.True(t, widens("int", "bigint"))
+	assert.True(t, widens("int unsigned", "bigint"))
+	assert.False(t, widens("int", "bigint unsigned"))
+	assert.False(t, widens("bigint", "int"))
+	assert.True(t, widens("varchar(100)", "varchar(255)"))
+	assert.True(t, widens("varchar(255)", "text"))
+	assert.False(t, widens("text", "varchar(255)"))
+	assert.False(t, widens("varchar(10)", "char(20)"))
+	assert.True(t, widens("varbinary(10)", "blob"))
+	assert.True(t, widens("decimal(10,2)", "decimal(12,2)"))
+	assert.False(t, widens("decimal(10,2)", "decimal(10,3)"))
+	assert.True(t, widens("float", "double"))
+	assert.True(t, widens("date", "datetime"))
+	assert.False(t, widens("int", "varchar(255)"))
+}
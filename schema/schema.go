@@ -0,0 +1,250 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+// Package schema derives table definitions from models.
+//	Only MySQL is supported, there is no option of dialect: column types and statements
+//	are always generated in MySQL syntax.
+package schema
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jasonjoo2010/godao/types"
+)
+
+var (
+	typeTime        = reflect.TypeOf(time.Time{})
+	typeNullString  = reflect.TypeOf(sql.NullString{})
+	typeNullInt64   = reflect.TypeOf(sql.NullInt64{})
+	typeNullInt32   = reflect.TypeOf(sql.NullInt32{})
+	typeNullFloat64 = reflect.TypeOf(sql.NullFloat64{})
+	typeNullBool    = reflect.TypeOf(sql.NullBool{})
+	typeNullTime    = reflect.TypeOf(sql.NullTime{})
+)
+
+// Index is a key defined by tags `index=name` or `unique=name`.
+//	Columns are in the order of fields.
+type Index struct {
+	Name    string
+	Unique  bool
+	Columns []string
+}
+
+// Nullable returns whether the column accepts null.
+//	Pointers and sql.Null* types are always nullable.
+func Nullable(f *types.ModelField) bool {
+	if f.Nullable || f.NullZero || f.Type.Kind() == reflect.Ptr {
+		return true
+	}
+	switch f.Type {
+	case typeNullString, typeNullInt64, typeNullInt32, typeNullFloat64, typeNullBool, typeNullTime:
+		return true
+	}
+	return false
+}
+
+// ColumnType returns the type of column in MySQL
+func ColumnType(f *types.ModelField) (string, error) {
+	typ := f.Type
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ {
+	case typeTime, typeNullTime:
+		return "datetime", nil
+	case typeNullString:
+		return stringType(f.Size), nil
+	case typeNullInt64:
+		return "bigint", nil
+	case typeNullInt32:
+		return "int", nil
+	case typeNullFloat64:
+		return "double", nil
+	case typeNullBool:
+		return "tinyint(1)", nil
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "tinyint(1)", nil
+	case reflect.Int8:
+		return "tinyint", nil
+	case reflect.Int16:
+		return "smallint", nil
+	case reflect.Int32:
+		return "int", nil
+	case reflect.Int, reflect.Int64:
+		return "bigint", nil
+	case reflect.Uint8:
+		return "tinyint unsigned", nil
+	case reflect.Uint16:
+		return "smallint unsigned", nil
+	case reflect.Uint32:
+		return "int unsigned", nil
+	case reflect.Uint, reflect.Uint64:
+		return "bigint unsigned", nil
+	case reflect.Float32:
+		return "float", nil
+	case reflect.Float64:
+		return "double", nil
+	case reflect.String:
+		return stringType(f.Size), nil
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			if f.Size > 0 {
+				return fmt.Sprintf("varbinary(%d)", f.Size), nil
+			}
+			return "blob", nil
+		}
+	}
+	return "", fmt.Errorf("Unsupported type of field %s: %v", f.Name, f.Type)
+}
+
+func stringType(size int) string {
+	switch {
+	case size == 0:
+		return "varchar(255)"
+	case size > 16383:
+		return "text"
+	}
+	return fmt.Sprintf("varchar(%d)", size)
+}
+
+var (
+	// types whose default values are quoted
+	quotedTypes = map[string]bool{
+		"char": true, "varchar": true, "binary": true, "varbinary": true, "enum": true, "set": true,
+		"date": true, "time": true, "datetime": true, "timestamp": true, "year": true,
+	}
+	// types which can't have default values
+	noDefaultTypes = map[string]bool{
+		"tinytext": true, "text": true, "mediumtext": true, "longtext": true,
+		"tinyblob": true, "blob": true, "mediumblob": true, "longblob": true,
+		"json": true, "geometry": true,
+	}
+)
+
+// quoteDefault returns the literal of default value for column of type.
+//	Columns of TEXT or BLOB can't have default values, and empty ones are only valid for quoted types.
+func quoteDefault(f *types.ModelField, columnType string) (string, error) {
+	upper := strings.ToUpper(f.Default)
+	if upper == "NULL" || strings.HasPrefix(upper, "CURRENT_TIMESTAMP") {
+		return upper, nil
+	}
+	base, _, _ := parseType(strings.ToLower(columnType))
+	switch {
+	case noDefaultTypes[base]:
+		return "", fmt.Errorf("Column %s of type %s can't have a default value", f.Column, columnType)
+	case quotedTypes[base]:
+		return "'" + strings.ReplaceAll(f.Default, "'", "''") + "'", nil
+	case strings.TrimSpace(f.Default) == "":
+		return "", fmt.Errorf("Default value of column %s of type %s is empty", f.Column, columnType)
+	}
+	return f.Default, nil
+}
+
+// ColumnDefinition returns the definition of column, e.g. "`name` varchar(100) not null default ''"
+func ColumnDefinition(f *types.ModelField) (string, error) {
+	columnType, err := ColumnType(f)
+	if err != nil {
+		return "", err
+	}
+	return columnDefinition(f, columnType)
+}
+
+// columnDefinition returns the definition of column in the given type
+func columnDefinition(f *types.ModelField, columnType string) (string, error) {
+	b := strings.Builder{}
+	b.WriteString("`")
+	b.WriteString(f.Column)
+	b.WriteString("` ")
+	b.WriteString(columnType)
+	if Nullable(f) {
+		b.WriteString(" null")
+	} else {
+		b.WriteString(" not null")
+	}
+	if f.AutoIncrement {
+		b.WriteString(" auto_increment")
+	}
+	if f.HasDefault {
+		literal, err := quoteDefault(f, columnType)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(" default ")
+		b.WriteString(literal)
+	}
+	return b.String(), nil
+}
+
+// Indexes returns keys defined by fields in the order of first appearance
+func Indexes(fields []*types.ModelField) []Index {
+	var indexes []Index
+	pos := make(map[string]int)
+	add := func(name, column string, unique bool) {
+		i, ok := pos[name]
+		if !ok {
+			i = len(indexes)
+			pos[name] = i
+			indexes = append(indexes, Index{Name: name, Unique: unique})
+		}
+		indexes[i].Columns = append(indexes[i].Columns, column)
+	}
+	for _, f := range fields {
+		for _, name := range f.Uniques {
+			add(name, f.Column, true)
+		}
+		for _, name := range f.Indexes {
+			add(name, f.Column, false)
+		}
+	}
+	return indexes
+}
+
+// IndexDefinition returns the definition of index, e.g. "unique key `uk_email` (`email`)"
+func IndexDefinition(index Index) string {
+	b := strings.Builder{}
+	if index.Unique {
+		b.WriteString("unique ")
+	}
+	b.WriteString("key `")
+	b.WriteString(index.Name)
+	b.WriteString("` (")
+	b.WriteString(joinColumns(index.Columns))
+	b.WriteString(")")
+	return b.String()
+}
+
+func joinColumns(columns []string) string {
+	return "`" + strings.Join(columns, "`, `") + "`"
+}
+
+// CreateTableSQL generates the statement creating table for fields in MySQL
+func CreateTableSQL(table string, fields []*types.ModelField) (string, error) {
+	definitions := make([]string, 0, len(fields)+1)
+	var primaries []string
+	for _, f := range fields {
+		definition, err := ColumnDefinition(f)
+		if err != nil {
+			return "", err
+		}
+		definitions = append(definitions, definition)
+		if f.Primary {
+			primaries = append(primaries, f.Column)
+		}
+	}
+	if len(primaries) > 0 {
+		definitions = append(definitions, "primary key ("+joinColumns(primaries)+")")
+	}
+	for _, index := range Indexes(fields) {
+		definitions = append(definitions, IndexDefinition(index))
+	}
+	return "create table `" + table + "` (\n  " +
+		strings.Join(definitions, ",\n  ") +
+		"\n) engine=InnoDB default charset=utf8mb4;", nil
+}
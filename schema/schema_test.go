@@ -0,0 +1,84 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package schema
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jasonjoo2010/godao/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type Account struct {
+	Id       int64  `dao:"primary;auto_increment"`
+	Email    string `dao:"size=100;unique=uk_email"`
+	Nickname string `dao:"size=50;nullable;default=guest;index=idx_name_age"`
+	Age      uint8  `dao:"default=0;index=idx_name_age"`
+	Score    float64
+	Avatar   []byte
+	Bio      sql.NullString `dao:"size=20000"`
+	Deleted  *time.Time
+	Created  time.Time `dao:"default=CURRENT_TIMESTAMP"`
+}
+
+type EmptyDefault struct {
+	Id  int64 `dao:"primary"`
+	Cnt int64 `dao:"default="`
+}
+
+type TextDefault struct {
+	Id  int64  `dao:"primary"`
+	Bio string `dao:"size=20000;default=x"`
+}
+
+type Unsupported struct {
+	Id   int64 `dao:"primary"`
+	Tags []string
+}
+
+func TestCreateTableSQL(t *testing.T) {
+	sql, err := CreateTableSQL("account", model.Parse(Account{}))
+	assert.Nil(t, err)
+	assert.Equal(t, "create table `account` (\n"+
+		"  `id` bigint not null auto_increment,\n"+
+		"  `email` varchar(100) not null,\n"+
+		"  `nickname` varchar(50) null default 'guest',\n"+
+		"  `age` tinyint unsigned not null default 0,\n"+
+		"  `score` double not null,\n"+
+		"  `avatar` blob not null,\n"+
+		"  `bio` text null,\n"+
+		"  `deleted` datetime null,\n"+
+		"  `created` datetime not null default CURRENT_TIMESTAMP,\n"+
+		"  primary key (`id`),\n"+
+		"  unique key `uk_email` (`email`),\n"+
+		"  key `idx_name_age` (`nickname`, `age`)\n"+
+		") engine=InnoDB default charset=utf8mb4;", sql)
+
+	_, err = CreateTableSQL("unsupported", model.Parse(Unsupported{}))
+	assert.NotNil(t, err)
+
+	// invalid defaults
+	_, err = CreateTableSQL("empty_default", model.Parse(EmptyDefault{}))
+	assert.NotNil(t, err)
+	_, err = CreateTableSQL("text_default", model.Parse(TextDefault{}))
+	assert.NotNil(t, err)
+}
+
+func TestQuoteDefault(t *testing.T) {
+	f := model.Parse(Account{})[2]
+	for typ, expected := range map[string]string{
+		"varchar(50)":         "'guest'",
+		"char(10)":            "'guest'",
+		"enum('guest','vip')": "'guest'",
+	} {
+		literal, err := quoteDefault(f, typ)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, literal, typ)
+	}
+	_, err := quoteDefault(f, "mediumtext")
+	assert.NotNil(t, err)
+}
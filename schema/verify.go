@@ -0,0 +1,154 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package schema
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jasonjoo2010/godao/types"
+)
+
+var (
+	typeScanner = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// VerifyError reports mismatches between model and live table
+type VerifyError struct {
+	Table    string
+	Problems []string
+}
+
+func (e *VerifyError) Error() string {
+	return "Model mismatches table `" + e.Table + "`:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// category of column types
+type category int
+
+const (
+	categoryUnknown category = iota
+	categoryInteger
+	categoryDecimal
+	categoryFloat
+	categoryString
+	categoryBinary
+	categoryTime
+)
+
+func columnCategory(columnType string) category {
+	typ := strings.ToLower(columnType)
+	if i := strings.IndexAny(typ, "( "); i > 0 {
+		typ = typ[:i]
+	}
+	switch typ {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "bit", "bool", "boolean", "year":
+		return categoryInteger
+	case "decimal", "numeric":
+		return categoryDecimal
+	case "float", "double", "real":
+		return categoryFloat
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext", "enum", "set", "json":
+		return categoryString
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		return categoryBinary
+	case "date", "datetime", "timestamp", "time":
+		return categoryTime
+	}
+	return categoryUnknown
+}
+
+// compatible returns whether values of column can be scanned into field
+func compatible(f *types.ModelField, c Column) bool {
+	typ := f.Type
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	cat := columnCategory(c.Type)
+	switch typ {
+	case typeTime, typeNullTime:
+		return cat == categoryTime
+	case typeNullString:
+		return cat != categoryUnknown
+	case typeNullInt64, typeNullInt32, typeNullBool:
+		return cat == categoryInteger
+	case typeNullFloat64:
+		return cat == categoryInteger || cat == categoryDecimal || cat == categoryFloat
+	}
+	if reflect.PtrTo(typ).Implements(typeScanner) {
+		// custom scanner
+		return true
+	}
+	switch typ.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cat == categoryInteger
+	case reflect.Float32, reflect.Float64:
+		return cat == categoryInteger || cat == categoryDecimal || cat == categoryFloat
+	case reflect.String:
+		return cat != categoryUnknown
+	case reflect.Slice:
+		return typ.Elem().Kind() == reflect.Uint8
+	}
+	return false
+}
+
+// Verify checks fields of model against the live table:
+//	fields without column, NOT NULL columns without default missing in model,
+//	incompatible types, nullability and primary keys.
+//	A *VerifyError is returned describing all problems.
+func Verify(table string, fields []*types.ModelField, live *Table) error {
+	if live == nil {
+		return &VerifyError{Table: table, Problems: []string{"table doesn't exist"}}
+	}
+	var problems []string
+	columns := make(map[string]Column, len(live.Columns))
+	for _, c := range live.Columns {
+		columns[strings.ToLower(c.Name)] = c
+	}
+	modeled := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		modeled[strings.ToLower(f.Column)] = true
+		c, ok := columns[strings.ToLower(f.Column)]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("field %s: column `%s` not found", f.Name, f.Column))
+			continue
+		}
+		if !compatible(f, c) {
+			problems = append(problems, fmt.Sprintf("field %s: type %v is incompatible with column `%s` %s", f.Name, f.Type, c.Name, c.Type))
+		}
+		if c.Nullable && !Nullable(f) && !reflect.PtrTo(f.Type).Implements(typeScanner) {
+			problems = append(problems, fmt.Sprintf("field %s: column `%s` is nullable but type %v can't hold null", f.Name, c.Name, f.Type))
+		}
+		if f.Primary != c.Primary {
+			if f.Primary {
+				problems = append(problems, fmt.Sprintf("field %s: column `%s` is not in primary key", f.Name, c.Name))
+			} else {
+				problems = append(problems, fmt.Sprintf("field %s: column `%s` is in primary key but field isn't tagged primary", f.Name, c.Name))
+			}
+		}
+		if f.AutoIncrement && !c.AutoIncrement {
+			problems = append(problems, fmt.Sprintf("field %s: column `%s` is not auto_increment", f.Name, c.Name))
+		}
+	}
+	for _, c := range live.Columns {
+		if modeled[strings.ToLower(c.Name)] {
+			continue
+		}
+		if !c.Nullable && !c.HasDefault && !c.AutoIncrement {
+			problems = append(problems, fmt.Sprintf("column `%s`: NOT NULL without default but missing in model", c.Name))
+		}
+		if c.Primary {
+			problems = append(problems, fmt.Sprintf("column `%s`: in primary key but missing in model", c.Name))
+		}
+	}
+	if len(problems) > 0 {
+		return &VerifyError{Table: table, Problems: problems}
+	}
+	return nil
+}
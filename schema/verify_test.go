@@ -0,0 +1,62 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package schema
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jasonjoo2010/godao/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type Member struct {
+	Id      int64 `dao:"primary;auto_increment"`
+	Name    string
+	Email   string `dao:"column=mail"`
+	Score   float64
+	Remark  sql.NullString
+	Created time.Time
+}
+
+func TestVerify(t *testing.T) {
+	fields := model.Parse(Member{})
+	live := &Table{Columns: []Column{
+		{Name: "id", Type: "bigint(20)", Primary: true, AutoIncrement: true},
+		{Name: "name", Type: "varchar(50)"},
+		{Name: "mail", Type: "varchar(100)"},
+		{Name: "score", Type: "decimal(10,2)"},
+		{Name: "remark", Type: "text", Nullable: true, HasDefault: true},
+		{Name: "created", Type: "datetime"},
+		{Name: "note", Type: "varchar(10)", HasDefault: true},
+	}}
+	assert.Nil(t, Verify("member", fields, live))
+
+	live = &Table{Columns: []Column{
+		{Name: "id", Type: "varchar(20)"},
+		{Name: "name", Type: "varchar(50)", Nullable: true, HasDefault: true},
+		{Name: "score", Type: "double"},
+		{Name: "remark", Type: "text", Nullable: true, HasDefault: true},
+		{Name: "created", Type: "bigint(20)"},
+		{Name: "code", Type: "varchar(10)", Primary: true},
+		{Name: "owner", Type: "bigint(20)"},
+	}}
+	err := Verify("member", fields, live)
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{
+		"field Id: type int64 is incompatible with column `id` varchar(20)",
+		"field Id: column `id` is not in primary key",
+		"field Id: column `id` is not auto_increment",
+		"field Name: column `name` is nullable but type string can't hold null",
+		"field Email: column `mail` not found",
+		"field Created: type time.Time is incompatible with column `created` bigint(20)",
+		"column `code`: NOT NULL without default but missing in model",
+		"column `code`: in primary key but missing in model",
+		"column `owner`: NOT NULL without default but missing in model",
+	}, err.(*VerifyError).Problems)
+
+	assert.NotNil(t, Verify("member", fields, nil))
+}
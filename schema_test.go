@@ -0,0 +1,50 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jasonjoo2010/godao/options"
+	"github.com/jasonjoo2010/godao/sharding"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateTable(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(Demo{}, db)
+
+	sqlStr, err := dao.CreateTableSQL()
+	assert.Nil(t, err)
+	assert.Equal(t, "create table `demo` (\n"+
+		"  `id` bigint not null auto_increment,\n"+
+		"  `name` varchar(100) not null default '',\n"+
+		"  `value` varchar(255) not null default '',\n"+
+		"  `cnt` bigint not null default 0,\n"+
+		"  `created` bigint not null default 0,\n"+
+		"  primary key (`id`),\n"+
+		"  key `name` (`name`)\n"+
+		") engine=InnoDB default charset=utf8mb4;", sqlStr)
+
+	mock.ExpectExec(sqlStr).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.Nil(t, dao.CreateTable(context.Background()))
+
+	// all shards
+	shardDao := NewDao(ShardedOrder{}, db, options.WithSharding(sharding.NewModulo("UserId", "order_%d", 2)))
+	for i := 0; i < 2; i++ {
+		mock.ExpectExec(fmt.Sprintf("create table `order_%d` (\n"+
+			"  `id` bigint not null auto_increment,\n"+
+			"  `user_id` bigint not null,\n"+
+			"  `amount` bigint not null,\n"+
+			"  primary key (`id`)\n"+
+			") engine=InnoDB default charset=utf8mb4;", i)).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	assert.Nil(t, shardDao.CreateTable(context.Background()))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
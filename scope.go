@@ -0,0 +1,111 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jasonjoo2010/godao/query"
+)
+
+// Scope returns a cheap copy of dao with the named scopes registered by options.WithScope() applied.
+//	Conditions of scopes are merged into the query given in following operations.
+func (dao *Dao) Scope(names ...string) *Dao {
+	clone := *dao
+	clone.scopes = make([]query.Data, len(dao.scopes), len(dao.scopes)+len(names))
+	copy(clone.scopes, dao.scopes)
+	for _, name := range names {
+		data, ok := dao.namedScopes[name]
+		if !ok {
+			panic("Unknown scope: " + name)
+		}
+		clone.scopes = append(clone.scopes, data)
+	}
+	return &clone
+}
+
+// Unscoped returns a cheap copy of dao without default scopes or named scopes applied.
+//	Tenant scoping is still in effect.
+func (dao *Dao) Unscoped() *Dao {
+	clone := *dao
+	clone.scopes = nil
+	return &clone
+}
+
+// scoped returns whether conditions should be merged into queries
+func (dao *Dao) scoped() bool {
+	return dao.tenant != nil || len(dao.scopes) > 0
+}
+
+// applyScopes merges tenant condition and scopes into data.
+//	Data joined by OR is wrapped as a child to keep its semantic.
+func (dao *Dao) applyScopes(ctx context.Context, data query.Data) (query.Data, error) {
+	if !dao.scoped() {
+		return data, nil
+	}
+	tenant, err := dao.tenantOf(ctx)
+	if dao.tenant != nil && err != nil {
+		return data, err
+	}
+	conditions, children := dao.scopeConditions(tenant)
+	if !data.Or {
+		data.Conditions = append(conditions, data.Conditions...)
+		data.Children = append(children, data.Children...)
+		return data, nil
+	}
+	inner := data
+	inner.Order = nil
+	inner.Offset = 0
+	inner.Limit = 0
+	return query.Data{
+		Conditions: conditions,
+		Children:   append(children, inner),
+		Order:      data.Order,
+		Offset:     data.Offset,
+		Limit:      data.Limit,
+	}, nil
+}
+
+// scopeSQL returns the condition of scopes joined by AND, which is appended to
+//	statements by primaries. The tenant condition is excluded because it's taken as primaries.
+func (dao *Dao) scopeSQL() (string, []interface{}) {
+	if len(dao.scopes) == 0 {
+		return "", nil
+	}
+	conditions, children := dao.scopeConditions(nil)
+	if dao.tenant != nil {
+		conditions = conditions[1:]
+	}
+	where, args := query.ConditionSQL(dao.fieldMap, dao.columnMap, &query.Data{
+		Conditions: conditions,
+		Children:   children,
+	})
+	return strings.TrimPrefix(where, "where "), args
+}
+
+// scopeConditions returns conditions and children of tenant and scopes joined by AND.
+//	The tenant condition is always the first one if the model has a tenant field.
+func (dao *Dao) scopeConditions(tenant interface{}) (conditions []query.Condition, children []query.Data) {
+	if dao.tenant != nil {
+		conditions = append(conditions, query.Condition{
+			Field: dao.tenant.Name,
+			Op:    query.OpEqual,
+			Value: tenant,
+		})
+	}
+	for _, s := range dao.scopes {
+		s.Order = nil
+		s.Offset = 0
+		s.Limit = 0
+		if s.Or {
+			children = append(children, s)
+			continue
+		}
+		conditions = append(conditions, s.Conditions...)
+		children = append(children, s.Children...)
+	}
+	return
+}
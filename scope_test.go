@@ -0,0 +1,72 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jasonjoo2010/godao/options"
+	"github.com/stretchr/testify/assert"
+)
+
+type Post struct {
+	Id        int64 `dao:"primary;auto_increment"`
+	Status    int
+	Views     int
+	Published bool
+}
+
+func TestScopes(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(Post{}, db,
+		options.WithDefaultScope((&Query{}).NotEqual("Status", -1).Data()),
+		options.WithScope("published", (&Query{}).Equal("Published", true).Data()),
+		options.WithScope("hot", (&Query{}).Greater("Views", 100).Equal("Status", 2).Or().Data()),
+	)
+	ctx := context.Background()
+
+	mock.ExpectQuery("select count(*) from `post` where `status` <> ? and `id` > ?").
+		WithArgs(-1, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"cnt"}).AddRow(1))
+	_, err := dao.Count(ctx, (&Query{}).Greater("Id", 0).Data())
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("select count(*) from `post` where `status` <> ? and `published` = ? and `id` > ? and (`views` > ? or `status` = ?)").
+		WithArgs(-1, true, 0, 100, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"cnt"}).AddRow(1))
+	_, err = dao.Scope("published", "hot").Count(ctx, (&Query{}).Greater("Id", 0).Data())
+	assert.Nil(t, err)
+
+	mock.ExpectExec("delete from `post` where `id` = ?").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = dao.Unscoped().Delete(ctx, 1)
+	assert.Nil(t, err)
+
+	// updating by primaries is scoped too
+	mock.ExpectBegin()
+	mock.ExpectExec("update `post` set `status` = ?, `views` = ?, `published` = ? where `id` = ? and `status` <> ? and (`views` > ? or `status` = ?)").
+		WithArgs(1, 0, false, int64(1), -1, 100, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	affected, err := dao.Scope("hot").Update(ctx, &Post{Id: 1, Status: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), affected)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("update `post` set `views` = ? where `id` = ?").
+		WithArgs(5, int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	affected, err = dao.Unscoped().UpdateFields(ctx, &Post{Id: 1, Views: 5}, "Views")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	assert.Panics(t, func() { dao.Scope("unknown") })
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
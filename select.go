@@ -0,0 +1,335 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jasonjoo2010/godao/model"
+	"github.com/jasonjoo2010/godao/options"
+	"github.com/jasonjoo2010/godao/query"
+	"github.com/jasonjoo2010/godao/types"
+)
+
+var (
+	errScattered = errors.New("Query across shards is not supported, please specify the shard key")
+	errIntoDest  = errors.New("Dest should be a pointer to slice of structs")
+	errPluckDest = errors.New("Dest should be a pointer to slice")
+)
+
+// SelectMaps returns rows matched by the condition as maps keyed by the aliases of fields.
+//	Expressions can be given any alias through options.WithFields, e.g. "avg(@Cnt@) as avg_cnt".
+//	Values are converted by database types of columns regardless of the protocol used by driver:
+//	integers as int64 (uint64 for unsigned bigint), floats as float64, decimals as int64 without scale
+//	or float64, strings as string, binaries as []byte and NULL as nil.
+//	For a sharding dao, the query must be routed to a single shard.
+func (dao *Dao) SelectMaps(ctx context.Context, data query.Data, opts ...options.SelectOption) (result []map[string]interface{}, err error) {
+	ctx, finish := dao.observe(ctx, "SelectMaps")
+	defer finish(&err)
+	err = dao.selectRaw(ctx, data, opts, func(aliases []string) (func(rows *sql.Rows) error, error) {
+		var columns []mapColumn
+		return func(rows *sql.Rows) error {
+			if columns == nil {
+				columnTypes, err := rows.ColumnTypes()
+				if err != nil {
+					return err
+				}
+				columns = make([]mapColumn, len(columnTypes))
+				for i, ct := range columnTypes {
+					columns[i] = newMapColumn(ct)
+				}
+			}
+			args := make([]interface{}, len(columns))
+			for i, c := range columns {
+				args[i] = c.dest()
+			}
+			if err := rows.Scan(args...); err != nil {
+				return err
+			}
+			m := make(map[string]interface{}, len(aliases))
+			for i, alias := range aliases {
+				m[alias] = columns[i].value(args[i])
+			}
+			result = append(result, m)
+			return nil
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+type mapKind int
+
+const (
+	mapKindRaw mapKind = iota
+	mapKindInt
+	mapKindUint
+	mapKindFloat
+	mapKindDecimal
+	mapKindString
+	mapKindBytes
+)
+
+// mapColumn scans a result column of SelectMaps by its database type
+type mapColumn struct {
+	kind  mapKind
+	scale int64
+}
+
+func newMapColumn(ct *sql.ColumnType) mapColumn {
+	name := strings.ToUpper(ct.DatabaseTypeName())
+	switch name {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "YEAR",
+		"UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED MEDIUMINT", "UNSIGNED INT":
+		return mapColumn{kind: mapKindInt}
+	case "UNSIGNED BIGINT":
+		return mapColumn{kind: mapKindUint}
+	case "FLOAT", "DOUBLE", "REAL":
+		return mapColumn{kind: mapKindFloat}
+	case "DECIMAL", "NUMERIC":
+		_, scale, _ := ct.DecimalSize()
+		return mapColumn{kind: mapKindDecimal, scale: scale}
+	case "CHAR", "VARCHAR", "TEXT", "TINYTEXT", "MEDIUMTEXT", "LONGTEXT", "ENUM", "SET", "JSON":
+		return mapColumn{kind: mapKindString}
+	case "BINARY", "VARBINARY", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BIT":
+		return mapColumn{kind: mapKindBytes}
+	}
+	return mapColumn{kind: mapKindRaw}
+}
+
+func (c mapColumn) dest() interface{} {
+	switch c.kind {
+	case mapKindInt:
+		return &sql.NullInt64{}
+	case mapKindFloat:
+		return &sql.NullFloat64{}
+	case mapKindUint, mapKindDecimal, mapKindString:
+		return &sql.NullString{}
+	case mapKindBytes:
+		return &[]byte{}
+	}
+	return new(interface{})
+}
+
+func (c mapColumn) value(dest interface{}) interface{} {
+	switch d := dest.(type) {
+	case *sql.NullInt64:
+		if d.Valid {
+			return d.Int64
+		}
+	case *sql.NullFloat64:
+		if d.Valid {
+			return d.Float64
+		}
+	case *sql.NullString:
+		if !d.Valid {
+			return nil
+		}
+		switch c.kind {
+		case mapKindUint:
+			if v, err := strconv.ParseUint(d.String, 10, 64); err == nil {
+				return v
+			}
+		case mapKindDecimal:
+			if c.scale == 0 {
+				if v, err := strconv.ParseInt(d.String, 10, 64); err == nil {
+					return v
+				}
+			}
+			if v, err := strconv.ParseFloat(d.String, 64); err == nil {
+				return v
+			}
+		}
+		return d.String
+	case *[]byte:
+		if *d != nil {
+			return *d
+		}
+	case *interface{}:
+		// unknown type, e.g. time
+		if b, ok := (*d).([]byte); ok {
+			return string(b)
+		}
+		return *d
+	}
+	return nil
+}
+
+// SelectInto fills dest, a pointer to slice of structs or struct pointers, with rows matched by the condition.
+//	Result columns are mapped to fields of DTO by name or by column name following the tags of model.
+//	For a sharding dao, the query must be routed to a single shard.
+func (dao *Dao) SelectInto(ctx context.Context, dest interface{}, data query.Data, opts ...options.SelectOption) (err error) {
+	ctx, finish := dao.observe(ctx, "SelectInto")
+	defer finish(&err)
+	destVal := reflect.ValueOf(dest)
+	if destVal.Kind() != reflect.Ptr || destVal.Elem().Kind() != reflect.Slice {
+		return errIntoDest
+	}
+	slice := destVal.Elem()
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return errIntoDest
+	}
+	slice.Set(slice.Slice(0, 0))
+	return dao.selectRaw(ctx, data, opts, func(aliases []string) (func(rows *sql.Rows) error, error) {
+		fields, err := dtoFields(structType, aliases)
+		if err != nil {
+			return nil, err
+		}
+		return func(rows *sql.Rows) error {
+			val := reflect.New(structType)
+			if err := scanFields(rows, val.Elem(), fields); err != nil {
+				return err
+			}
+			if elemType.Kind() != reflect.Ptr {
+				val = val.Elem()
+			}
+			slice.Set(reflect.Append(slice, val))
+			return nil
+		}, nil
+	})
+}
+
+// Pluck scans values of single field matched by the condition into dest, a pointer to typed slice
+//	like *[]int64 or *[]string. Use options.WithDistinct() to remove duplicated values,
+//	and query.Values() to build `in` conditions of follow-up queries from dest.
+//	For a sharding dao, the query must be routed to a single shard.
+func (dao *Dao) Pluck(ctx context.Context, field string, data query.Data, dest interface{}, opts ...options.SelectOption) (err error) {
+	ctx, finish := dao.observe(ctx, "Pluck")
+	defer finish(&err)
+	destVal := reflect.ValueOf(dest)
+	if destVal.Kind() != reflect.Ptr || destVal.Elem().Kind() != reflect.Slice {
+		return errPluckDest
+	}
+	slice := destVal.Elem()
+	elemType := slice.Type().Elem()
+	nullZero := false
+	if f := options.ParseSelectField(field, dao.fieldMap, dao.columnMap); f != nil && f.Expr == "" {
+		nullZero = f.Field.NullZero && elemType.Kind() != reflect.Ptr
+	}
+	slice.Set(slice.Slice(0, 0))
+	opts = append(opts, options.WithFields(field))
+	return dao.selectRaw(ctx, data, opts, func(aliases []string) (func(rows *sql.Rows) error, error) {
+		return func(rows *sql.Rows) error {
+			if nullZero {
+				holder := reflect.New(reflect.PtrTo(elemType))
+				if err := rows.Scan(holder.Interface()); err != nil {
+					return err
+				}
+				if ptr := holder.Elem(); ptr.IsNil() {
+					slice.Set(reflect.Append(slice, reflect.Zero(elemType)))
+				} else {
+					slice.Set(reflect.Append(slice, ptr.Elem()))
+				}
+				return nil
+			}
+			val := reflect.New(elemType)
+			if err := rows.Scan(val.Interface()); err != nil {
+				return err
+			}
+			slice.Set(reflect.Append(slice, val.Elem()))
+			return nil
+		}, nil
+	})
+}
+
+// dtoFields returns fields of DTO in the order of aliases
+func dtoFields(typ reflect.Type, aliases []string) ([]*types.ModelField, error) {
+	byName := make(map[string]*types.ModelField, typ.NumField())
+	byColumn := make(map[string]*types.ModelField, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if sf.PkgPath != "" {
+			// unexported
+			continue
+		}
+		f := model.ParseTag(sf.Name, sf.Tag)
+		if f == nil {
+			continue
+		}
+		f.Index = i
+		f.Type = sf.Type
+		byName[f.Name] = f
+		byColumn[f.Column] = f
+	}
+	fields := make([]*types.ModelField, len(aliases))
+	for i, alias := range aliases {
+		f, ok := byName[alias]
+		if !ok {
+			f, ok = byColumn[alias]
+		}
+		if !ok {
+			return nil, fmt.Errorf("No field of %s matches column %s", typ.Name(), alias)
+		}
+		fields[i] = f
+	}
+	return fields, nil
+}
+
+// selectRaw queries with free aliases and scans rows by the function built from aliases
+func (dao *Dao) selectRaw(
+	ctx context.Context,
+	data query.Data,
+	opts []options.SelectOption,
+	scanner func(aliases []string) (func(rows *sql.Rows) error, error),
+) error {
+	cfg := options.SelectOptions{}
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+	data, err := dao.applyScopes(ctx, data)
+	if err != nil {
+		return err
+	}
+	shards, err := dao.route(ctx, &data)
+	if err != nil {
+		return err
+	}
+	if len(shards) > 1 {
+		return errScattered
+	}
+	shard := shards[0]
+	condition, args := query.ConditionSQL(dao.fieldMap, dao.columnMap, &data)
+	if len(cfg.Fields) == 0 {
+		cfg.Fields = dao.selectColumns
+	}
+	sqlSelect, aliases := options.GenerateSelectExprs(cfg.Fields, dao.fieldMap, dao.columnMap)
+	fn, err := scanner(aliases)
+	if err != nil {
+		return err
+	}
+	e, err := dao.shardReadExecutor(ctx, shard, cfg.Primary)
+	if err != nil {
+		return err
+	}
+	sqlBuilder := strings.Builder{}
+	sqlBuilder.WriteString("select ")
+	if cfg.Distinct {
+		sqlBuilder.WriteString("distinct ")
+	}
+	sqlBuilder.WriteString(sqlSelect)
+	sqlBuilder.WriteString(" from `")
+	sqlBuilder.WriteString(shard.Table)
+	sqlBuilder.WriteString("`")
+	if condition != "" {
+		sqlBuilder.WriteString(" ")
+		sqlBuilder.WriteString(condition)
+	}
+	sqlBuilder.WriteString(";")
+	_, err = dao.query(ctx, e, types.OperationSelect, fn, shard.Table, sqlBuilder.String(), args...)
+	return err
+}
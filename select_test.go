@@ -0,0 +1,163 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jasonjoo2010/godao/options"
+	"github.com/jasonjoo2010/godao/query"
+	"github.com/jasonjoo2010/godao/sharding"
+	"github.com/stretchr/testify/assert"
+)
+
+type DemoReport struct {
+	Name   string
+	Total  int64 `dao:"column=total_cnt"`
+	AvgCnt *int64
+}
+
+func TestSelectMaps(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(Demo{}, db)
+	ctx := context.Background()
+
+	mock.ExpectQuery("select `name` as `Name`, sum(`cnt`) as `total_cnt` from `demo` where `id` > ?;").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"Name", "total_cnt"}).AddRow([]byte("a"), 5).AddRow("b", nil))
+	list, err := dao.SelectMaps(ctx, (&Query{}).Greater("Id", 3).Data(),
+		options.WithFields("Name", "sum(@Cnt@) as total_cnt"))
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"Name": "a", "total_cnt": int64(5)},
+		{"Name": "b", "total_cnt": nil},
+	}, list)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestSelectMapsStmtCache(t *testing.T) {
+	sqlStr := "select `name` as `Name`, sum(`cnt`) as `total_cnt`, avg(`cnt`) as `avg_cnt` from `demo` where `id` > ?;"
+	expected := []map[string]interface{}{
+		{"Name": "a", "total_cnt": int64(5), "avg_cnt": 2.5},
+		{"Name": "b", "total_cnt": nil, "avg_cnt": nil},
+	}
+	columns := []*sqlmock.Column{
+		sqlmock.NewColumn("Name").OfType("VARCHAR", ""),
+		sqlmock.NewColumn("total_cnt").OfType("DECIMAL", "").WithPrecisionAndScale(32, 0).Nullable(true),
+		sqlmock.NewColumn("avg_cnt").OfType("DECIMAL", "").WithPrecisionAndScale(14, 4).Nullable(true),
+	}
+
+	for _, cached := range []bool{false, true} {
+		db, mock := mockDB(t)
+		var opts []options.DaoOption
+		var expect *sqlmock.ExpectedQuery
+		rows := sqlmock.NewRowsWithColumnDefinition(columns...)
+		if cached {
+			// binary protocol returns typed values
+			opts = append(opts, options.WithStmtCache(10))
+			expect = mock.ExpectPrepare(sqlStr).ExpectQuery()
+			rows.AddRow("a", int64(5), 2.5).AddRow("b", nil, nil)
+		} else {
+			// text protocol returns everything as bytes
+			expect = mock.ExpectQuery(sqlStr)
+			rows.AddRow([]byte("a"), []byte("5"), []byte("2.5000")).AddRow([]byte("b"), nil, nil)
+		}
+		expect.WithArgs(3).WillReturnRows(rows)
+
+		dao := NewDao(Demo{}, db, opts...)
+		list, err := dao.SelectMaps(context.Background(), (&Query{}).Greater("Id", 3).Data(),
+			options.WithFields("Name", "sum(@Cnt@) as total_cnt", "avg(@Cnt@) as avg_cnt"))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, list, "cached: %v", cached)
+		assert.Nil(t, mock.ExpectationsWereMet())
+		db.Close()
+	}
+}
+
+func TestSelectInto(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(Demo{}, db)
+	ctx := context.Background()
+	columns := []string{"Name", "total_cnt", "AvgCnt"}
+	sqlStr := "select `name` as `Name`, count(*) as `total_cnt`, avg(`cnt`) as `AvgCnt` from `demo` where `id` > ?;"
+	fields := options.WithFields("Name", "count(*) as total_cnt", "avg(@Cnt@) as AvgCnt")
+
+	mock.ExpectQuery(sqlStr).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("a", 2, 4).AddRow("b", 1, nil))
+	var reports []DemoReport
+	err := dao.SelectInto(ctx, &reports, (&Query{}).Greater("Id", 3).Data(), fields)
+	assert.Nil(t, err)
+	avg := int64(4)
+	assert.Equal(t, []DemoReport{{"a", 2, &avg}, {"b", 1, nil}}, reports)
+
+	mock.ExpectQuery(sqlStr).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("a", 2, 4))
+	var ptrs []*DemoReport
+	err = dao.SelectInto(ctx, &ptrs, (&Query{}).Greater("Id", 3).Data(), fields)
+	assert.Nil(t, err)
+	assert.Equal(t, []*DemoReport{{"a", 2, &avg}}, ptrs)
+
+	// invalid dest or unmatched column
+	assert.NotNil(t, dao.SelectInto(ctx, reports, (&Query{}).Data()))
+	assert.NotNil(t, dao.SelectInto(ctx, &[]int64{}, (&Query{}).Data()))
+	assert.NotNil(t, dao.SelectInto(ctx, &reports, (&Query{}).Data(), options.WithFields("Id")))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestSelectMapsSharding(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(ShardedOrder{}, db, options.WithSharding(sharding.NewModulo("UserId", "order_%d", 4)))
+	ctx := context.Background()
+
+	_, err := dao.SelectMaps(ctx, (&Query{}).Data())
+	assert.Equal(t, errScattered, err)
+
+	mock.ExpectQuery("select sum(`amount`) as `total` from `order_1` where `user_id` = ?;").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(10))
+	list, err := dao.SelectMaps(ctx, (&Query{}).Equal("UserId", 5).Data(), options.WithFields("sum(@Amount@) as total"))
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]interface{}{{"total": int64(10)}}, list)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestPluck(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(Demo{}, db)
+	ctx := context.Background()
+
+	mock.ExpectQuery("select distinct `id` as `Id` from `demo` where `name` like ?;").
+		WithArgs("a%").
+		WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1).AddRow(3))
+	var ids []int64
+	err := dao.Pluck(ctx, "Id", (&Query{}).StartsWith("Name", "a").Data(), &ids, options.WithDistinct())
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{1, 3}, ids)
+
+	// follow-up query
+	mock.ExpectQuery("select `name` as `Name` from `demo` where `id` in (?, ?);").
+		WithArgs(int64(1), int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"Name"}).AddRow("a1").AddRow(nil))
+	var names []*string
+	err = dao.Pluck(ctx, "name", (&Query{}).In("Id", query.Values(ids)).Data(), &names)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(names))
+	assert.Equal(t, "a1", *names[0])
+	assert.Nil(t, names[1])
+
+	assert.Equal(t, errPluckDest, dao.Pluck(ctx, "Id", (&Query{}).Data(), ids))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
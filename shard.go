@@ -0,0 +1,284 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/jasonjoo2010/godao/model"
+	"github.com/jasonjoo2010/godao/query"
+	"github.com/jasonjoo2010/godao/sharding"
+	"github.com/jasonjoo2010/godao/types"
+)
+
+var (
+	errCrossDBTxn = errors.New("Transaction across databases is not supported")
+)
+
+// route returns shards matched by the shard key in top level conditions joined by AND.
+//	All shards are returned when shard key is absent.
+func (dao *Dao) route(ctx context.Context, data *query.Data) ([]sharding.Shard, error) {
+	if dao.sharding == nil {
+		return []sharding.Shard{{Table: dao.tableOf(ctx)}}, nil
+	}
+	if !data.Or {
+		for _, c := range data.Conditions {
+			if c.Op != query.OpEqual && c.Op != query.OpIn {
+				continue
+			}
+			if query.GetColumn(c.Field, dao.fieldMap, dao.columnMap, false) != dao.shardKey.Column {
+				continue
+			}
+			if c.Op == query.OpEqual {
+				shard, err := dao.sharding.Locate(c.Value)
+				if err != nil {
+					return nil, err
+				}
+				return []sharding.Shard{shard}, nil
+			}
+			arr, _ := c.Value.([]interface{})
+			shards := make([]sharding.Shard, 0, len(arr))
+			located := make(map[sharding.Shard]bool, len(arr))
+			for _, v := range arr {
+				shard, err := dao.sharding.Locate(v)
+				if err != nil {
+					return nil, err
+				}
+				if !located[shard] {
+					located[shard] = true
+					shards = append(shards, shard)
+				}
+			}
+			return shards, nil
+		}
+	}
+	return dao.sharding.Shards(), nil
+}
+
+// locate returns the shard which the object belongs to.
+//	If the shard key is the tenant field, the tenant in context which overrides the field is used.
+func (dao *Dao) locate(ctx context.Context, obj interface{}) (sharding.Shard, error) {
+	if dao.sharding == nil {
+		return sharding.Shard{Table: dao.tableOf(ctx)}, nil
+	}
+	if dao.shardKey.Tenant {
+		tenant, err := dao.tenantOf(ctx)
+		if err != nil {
+			return sharding.Shard{}, err
+		}
+		return dao.sharding.Locate(tenant)
+	}
+	val := reflect.ValueOf(model.RealValue(obj))
+	if val.Type() != dao.modelType {
+		return sharding.Shard{}, errors.New("The type of given object is unexpected")
+	}
+	return dao.sharding.Locate(val.Field(dao.shardKey.Index).Interface())
+}
+
+// shardExecutor returns the executor for writing into shard
+func (dao *Dao) shardExecutor(ctx context.Context, shard sharding.Shard) (executor, error) {
+	if shard.DB == nil || shard.DB == dao.db {
+		return dao.executor(ctx), nil
+	}
+	if _, ok := ctx.(*DaoTxnContext); ok {
+		return nil, errCrossDBTxn
+	}
+	return dao.prepared(shard.DB, nil), nil
+}
+
+// shardReadExecutor returns the executor for reading from shard
+func (dao *Dao) shardReadExecutor(ctx context.Context, shard sharding.Shard, primary bool) (executor, error) {
+	if shard.DB == nil || shard.DB == dao.db {
+		return dao.readExecutor(ctx, primary), nil
+	}
+	if _, ok := ctx.(*DaoTxnContext); ok {
+		return nil, errCrossDBTxn
+	}
+	return dao.prepared(shard.DB, nil), nil
+}
+
+// shardTxns holds transactions opened for batch writing across shards.
+//	The transaction in context is used if there is.
+//	Statements are prepared once per batch if statement cache is enabled.
+type shardTxns struct {
+	ctx       context.Context
+	dao       *Dao
+	txns      map[*sql.DB]*sql.Tx
+	executors map[*sql.DB]executor
+}
+
+func (t *shardTxns) executor(shard sharding.Shard) (executor, error) {
+	db := shard.DB
+	if db == nil {
+		db = t.dao.db
+	}
+	if e, ok := t.executors[db]; ok {
+		return e, nil
+	}
+	var e executor
+	if _, ok := t.ctx.(*DaoTxnContext); ok {
+		var err error
+		if e, err = t.dao.shardExecutor(t.ctx, shard); err != nil {
+			return nil, err
+		}
+	} else {
+		txn, err := db.BeginTx(t.ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		if t.txns == nil {
+			t.txns = make(map[*sql.DB]*sql.Tx)
+		}
+		t.txns[db] = txn
+		e = t.dao.prepared(db, txn)
+	}
+	if p, ok := e.(*preparedExecutor); ok {
+		p.batch = make(map[string]*sql.Stmt)
+	}
+	if t.executors == nil {
+		t.executors = make(map[*sql.DB]executor)
+	}
+	t.executors[db] = e
+	return e, nil
+}
+
+// finish commits the transactions opened or rolls them back if *err is not nil.
+//	Failure of committing is set into *err. It returns whether the writing is rolled back,
+//	which is never done to the transaction in context.
+func (t *shardTxns) finish(err *error) (rolledBack bool) {
+	for _, txn := range t.txns {
+		if *err != nil {
+			txn.Rollback()
+			rolledBack = true
+			continue
+		}
+		if e := txn.Commit(); e != nil {
+			*err = e
+			rolledBack = true
+		}
+	}
+	for _, e := range t.executors {
+		if p, ok := e.(*preparedExecutor); ok {
+			p.close()
+		}
+	}
+	return
+}
+
+// unselectedOrders returns names of fields ordered by but not selected
+func (dao *Dao) unselectedOrders(data *query.Data, selected []*types.ModelField) []string {
+	var missing []string
+	for _, o := range data.Order {
+		f := dao.columnMap[query.GetColumn(o.Field, dao.fieldMap, dao.columnMap, true)]
+		found := false
+		for _, s := range selected {
+			if s == f {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, f.Name)
+		}
+	}
+	return missing
+}
+
+// mergeShards merges the objects fetched from shards by order and applies offset and limit in memory
+func (dao *Dao) mergeShards(result []interface{}, data *query.Data) []interface{} {
+	if len(data.Order) > 0 {
+		fields := make([]int, len(data.Order))
+		for i, o := range data.Order {
+			column := query.GetColumn(o.Field, dao.fieldMap, dao.columnMap, true)
+			fields[i] = dao.columnMap[column].Index
+		}
+		sort.SliceStable(result, func(i, j int) bool {
+			a := reflect.ValueOf(result[i]).Elem()
+			b := reflect.ValueOf(result[j]).Elem()
+			for k, o := range data.Order {
+				c := compareValue(a.Field(fields[k]), b.Field(fields[k]))
+				if c == 0 {
+					continue
+				}
+				if o.Desc {
+					return c > 0
+				}
+				return c < 0
+			}
+			return false
+		})
+	}
+	if data.Offset > 0 {
+		if data.Offset >= len(result) {
+			return nil
+		}
+		result = result[data.Offset:]
+	}
+	if data.Limit > 0 && len(result) > data.Limit {
+		result = result[:data.Limit]
+	}
+	return result
+}
+
+func compareValue(a, b reflect.Value) int {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, y := a.Int(), b.Int()
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x, y := a.Uint(), b.Uint()
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	case reflect.Float32, reflect.Float64:
+		x, y := a.Float(), b.Float()
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	case reflect.String:
+		x, y := a.String(), b.String()
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	case reflect.Bool:
+		x, y := a.Bool(), b.Bool()
+		switch {
+		case !x && y:
+			return -1
+		case x && !y:
+			return 1
+		}
+	case reflect.Struct:
+		if x, ok := a.Interface().(time.Time); ok {
+			y := b.Interface().(time.Time)
+			switch {
+			case x.Before(y):
+				return -1
+			case x.After(y):
+				return 1
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,141 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jasonjoo2010/godao/options"
+	"github.com/jasonjoo2010/godao/query"
+	"github.com/jasonjoo2010/godao/sharding"
+	"github.com/stretchr/testify/assert"
+)
+
+type ShardedOrder struct {
+	Id     int64 `dao:"primary;auto_increment"`
+	UserId int64
+	Amount int64
+}
+
+func TestSharding(t *testing.T) {
+	db0, mock0 := mockDB(t)
+	defer db0.Close()
+	db1, mock1 := mockDB(t)
+	defer db1.Close()
+	dao := NewDao(ShardedOrder{}, db0, options.WithSharding(sharding.NewModulo("UserId", "order_%02d", 4, db0, db1)))
+	columns := []string{"Id", "UserId", "Amount"}
+
+	// insert routed by object
+	mock1.ExpectBegin()
+	mock1.ExpectExec("insert into `order_02` (`id`, `user_id`, `amount`) values (?, ?, ?);").
+		WithArgs(int64(0), int64(6), int64(100)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock1.ExpectCommit()
+	affected, id, err := dao.Insert(context.Background(), &ShardedOrder{UserId: 6, Amount: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), affected)
+	assert.Equal(t, int64(1), id)
+
+	// select routed by condition
+	mock0.ExpectQuery("select `id` as `Id`, `user_id` as `UserId`, `amount` as `Amount` from `order_01` where `user_id` = ?;").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(2, 5, 10))
+	list, err := dao.SelectBy(context.Background(), "UserId", 5, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(list))
+
+	// update routed by object
+	mock0.ExpectBegin()
+	mock0.ExpectExec("update `order_01` set `user_id` = ?, `amount` = ? where `id` = ?").
+		WithArgs(int64(5), int64(20), int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock0.ExpectCommit()
+	affected, err = dao.Update(context.Background(), &ShardedOrder{Id: 2, UserId: 5, Amount: 20})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	// scatter and gather
+	sqlStr := "select `id` as `Id`, `user_id` as `UserId`, `amount` as `Amount` from `%s` where `amount` > ? order by `amount` desc limit 0, 3;"
+	mock0.ExpectQuery(fmt.Sprintf(sqlStr, "order_00")).WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 4, 50).AddRow(3, 4, 10))
+	mock0.ExpectQuery(fmt.Sprintf(sqlStr, "order_01")).WillReturnRows(sqlmock.NewRows(columns).AddRow(2, 5, 40))
+	mock1.ExpectQuery(fmt.Sprintf(sqlStr, "order_02")).WillReturnRows(sqlmock.NewRows(columns))
+	mock1.ExpectQuery(fmt.Sprintf(sqlStr, "order_03")).WillReturnRows(sqlmock.NewRows(columns).AddRow(4, 7, 30).AddRow(5, 7, 20))
+	list, err = dao.Select(context.Background(), (&Query{}).
+		Greater("Amount", 0).
+		OrderBy("Amount", true).
+		Offset(1, 2).
+		Data())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(list))
+	assert.Equal(t, int64(40), list[0].(*ShardedOrder).Amount)
+	assert.Equal(t, int64(30), list[1].(*ShardedOrder).Amount)
+
+	// fields ordered by are selected for merging
+	sqlStr = "select `id` as `Id`, `amount` as `Amount` from `%s` order by `amount` asc limit 0, 1;"
+	mock0.ExpectQuery(fmt.Sprintf(sqlStr, "order_00")).WillReturnRows(sqlmock.NewRows([]string{"Id", "Amount"}).AddRow(1, 50))
+	mock0.ExpectQuery(fmt.Sprintf(sqlStr, "order_01")).WillReturnRows(sqlmock.NewRows([]string{"Id", "Amount"}).AddRow(2, 40))
+	mock1.ExpectQuery(fmt.Sprintf(sqlStr, "order_02")).WillReturnRows(sqlmock.NewRows([]string{"Id", "Amount"}))
+	mock1.ExpectQuery(fmt.Sprintf(sqlStr, "order_03")).WillReturnRows(sqlmock.NewRows([]string{"Id", "Amount"}).AddRow(5, 20))
+	list, err = dao.Select(context.Background(), (&Query{}).OrderBy("Amount", false).Limit(1).Data(), options.WithFields("Id"))
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{&ShardedOrder{Id: 5, Amount: 20}}, list)
+
+	// aggregation
+	mock0.ExpectQuery("select count(*) from `order_00` where `user_id` in (?, ?)").WillReturnRows(sqlmock.NewRows([]string{"cnt"}).AddRow(2))
+	mock0.ExpectQuery("select count(*) from `order_01` where `user_id` in (?, ?)").WillReturnRows(sqlmock.NewRows([]string{"cnt"}).AddRow(1))
+	cnt, err := dao.Count(context.Background(), (&Query{}).In("UserId", []interface{}{4, 5}).Data())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), cnt)
+
+	// transaction can't cross databases
+	mock0.ExpectBegin()
+	mock0.ExpectRollback()
+	ctx, err := dao.Txn(nil)
+	assert.Nil(t, err)
+	_, err = dao.Count(ctx, (&Query{}).Equal("UserId", 6).Data())
+	assert.NotNil(t, err)
+	ctx.Txn().Rollback()
+
+	assert.Nil(t, mock0.ExpectationsWereMet())
+	assert.Nil(t, mock1.ExpectationsWereMet())
+}
+
+func TestRouteShards(t *testing.T) {
+	db, _ := mockDB(t)
+	defer db.Close()
+	dao := NewDao(ShardedOrder{}, db, options.WithSharding(sharding.NewModulo("UserId", "order_%d", 4)))
+
+	shards, err := dao.route(context.Background(), &query.Data{})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(shards))
+
+	data := (&Query{}).Equal("user_id", 3).Data()
+	shards, err = dao.route(context.Background(), &data)
+	assert.Nil(t, err)
+	assert.Equal(t, []sharding.Shard{{Table: "order_3"}}, shards)
+
+	data = (&Query{}).In("UserId", []interface{}{1, 5, 2}).Data()
+	shards, err = dao.route(context.Background(), &data)
+	assert.Nil(t, err)
+	assert.Equal(t, []sharding.Shard{{Table: "order_1"}, {Table: "order_2"}}, shards)
+
+	// nil shard key
+	data = (&Query{}).Equal("UserId", nil).Data()
+	_, err = dao.route(context.Background(), &data)
+	assert.NotNil(t, err)
+
+	// or
+	data = (&Query{}).Equal("UserId", 1).Equal("Id", 3).Or().Data()
+	shards, err = dao.route(context.Background(), &data)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(shards))
+
+	assert.Panics(t, func() {
+		NewDao(ShardedOrder{}, db, options.WithSharding(sharding.NewModulo("Uid", "order_%d", 4)))
+	})
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package sharding
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"reflect"
+)
+
+var (
+	errNilKey = errors.New("Shard key is nil")
+)
+
+// Shard locates a table in a specific database.
+type Shard struct {
+	// DB of the shard, nil means the db of dao
+	DB    *sql.DB
+	Table string
+}
+
+// Strategy maps the value of shard key to shard.
+type Strategy interface {
+	// Field returns the name of shard key field
+	Field() string
+	// Locate returns the shard which the value belongs to
+	Locate(value interface{}) (Shard, error)
+	// Shards returns all shards used in scatter-gather when shard key is absent
+	Shards() []Shard
+}
+
+type modulo struct {
+	field  string
+	shards []Shard
+}
+
+// NewModulo creates a strategy distributing rows into tables by value of shard key modulo count.
+//	Table names are generated by format and index, e.g. "order_%02d" produces order_00 ... order_63.
+//	Tables are spread into dbs evenly in order if any given, otherwise they're all in the db of dao.
+//	Values of integer types are used directly while strings are hashed.
+func NewModulo(field, format string, count int, dbs ...*sql.DB) Strategy {
+	if count < 1 {
+		panic("Count of shards should be positive")
+	}
+	s := &modulo{
+		field:  field,
+		shards: make([]Shard, count),
+	}
+	for i := 0; i < count; i++ {
+		s.shards[i].Table = fmt.Sprintf(format, i)
+		if len(dbs) > 0 {
+			s.shards[i].DB = dbs[i*len(dbs)/count]
+		}
+	}
+	return s
+}
+
+func (s *modulo) Field() string {
+	return s.field
+}
+
+func (s *modulo) Locate(value interface{}) (Shard, error) {
+	n, err := toUint64(value)
+	if err != nil {
+		return Shard{}, err
+	}
+	return s.shards[n%uint64(len(s.shards))], nil
+}
+
+func (s *modulo) Shards() []Shard {
+	return s.shards
+}
+
+func toUint64(value interface{}) (uint64, error) {
+	val := reflect.ValueOf(value)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return 0, errNilKey
+		}
+		val = val.Elem()
+	}
+	if !val.IsValid() {
+		return 0, errNilKey
+	}
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := val.Int()
+		if n < 0 {
+			n = -n
+		}
+		return uint64(n), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return val.Uint(), nil
+	case reflect.String:
+		return uint64(crc32.ChecksumIEEE([]byte(val.String()))), nil
+	}
+	return 0, fmt.Errorf("Unsupported type of shard key: %v", val.Type())
+}
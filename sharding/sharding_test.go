@@ -0,0 +1,55 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package sharding
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModulo(t *testing.T) {
+	s := NewModulo("UserId", "order_%02d", 64)
+	assert.Equal(t, "UserId", s.Field())
+	assert.Equal(t, 64, len(s.Shards()))
+	assert.Equal(t, "order_00", s.Shards()[0].Table)
+	assert.Equal(t, "order_63", s.Shards()[63].Table)
+
+	shard, err := s.Locate(int64(65))
+	assert.Nil(t, err)
+	assert.Equal(t, "order_01", shard.Table)
+	assert.Nil(t, shard.DB)
+
+	uid := uint32(130)
+	shard, err = s.Locate(&uid)
+	assert.Nil(t, err)
+	assert.Equal(t, "order_02", shard.Table)
+
+	shard1, err := s.Locate("user-1")
+	assert.Nil(t, err)
+	shard2, err := s.Locate("user-1")
+	assert.Nil(t, err)
+	assert.Equal(t, shard1, shard2)
+
+	_, err = s.Locate(1.5)
+	assert.NotNil(t, err)
+	_, err = s.Locate(nil)
+	assert.NotNil(t, err)
+	_, err = s.Locate((*int64)(nil))
+	assert.NotNil(t, err)
+
+	assert.Panics(t, func() { NewModulo("UserId", "order_%02d", 0) })
+}
+
+func TestModuloDatabases(t *testing.T) {
+	db0, db1 := &sql.DB{}, &sql.DB{}
+	s := NewModulo("UserId", "order_%d", 4, db0, db1)
+	shards := s.Shards()
+	assert.True(t, shards[0].DB == db0)
+	assert.True(t, shards[1].DB == db0)
+	assert.True(t, shards[2].DB == db1)
+	assert.True(t, shards[3].DB == db1)
+}
@@ -0,0 +1,155 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jasonjoo2010/godao/types"
+)
+
+// executor is implemented by both *sql.DB and *sql.Tx
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// executor returns the transaction in context or the primary db
+func (dao *Dao) executor(ctx context.Context) executor {
+	if txnCtx, ok := ctx.(*DaoTxnContext); ok {
+		return dao.txnExecutor(txnCtx)
+	}
+	return dao.prepared(dao.db, nil)
+}
+
+// txnExecutor returns the transaction in context
+func (dao *Dao) txnExecutor(txnCtx *DaoTxnContext) executor {
+	db, _ := txnCtx.Value(internal_TXN_DB).(*sql.DB)
+	return dao.prepared(db, txnCtx.Txn())
+}
+
+// readExecutor returns the transaction in context, the primary db if it's forced,
+// or one of replicas.
+func (dao *Dao) readExecutor(ctx context.Context, primary bool) executor {
+	if txnCtx, ok := ctx.(*DaoTxnContext); ok {
+		return dao.txnExecutor(txnCtx)
+	}
+	if primary || len(dao.replicas) == 0 || IsPrimaryForced(ctx) {
+		return dao.prepared(dao.db, nil)
+	}
+	return dao.prepared(dao.replicas[dao.balancer.Next(len(dao.replicas))], nil)
+}
+
+// observe notifies observers that a public method is invoked.
+//	It returns the context used through the call and the function finishing it with the error.
+//	Calls nested in an observed one of the same dao are not observed again,
+//	while calls of other daos, e.g. from hooks, are.
+func (dao *Dao) observe(ctx context.Context, method string) (context.Context, func(err *error)) {
+	if len(dao.observers) == 0 || ctx.Value(internal_CALL) == dao {
+		return ctx, func(err *error) {}
+	}
+	_, inTxn := ctx.(*DaoTxnContext)
+	call := &types.Call{
+		Method: method,
+		Table:  dao.cacheTable(ctx),
+	}
+	ctx = context.WithValue(ctx, internal_CALL, dao)
+	finishes := make([]func(err error), len(dao.observers))
+	for i, observer := range dao.observers {
+		ctx, finishes[i] = observer(ctx, call)
+	}
+	if _, ok := ctx.(*DaoTxnContext); inTxn && !ok {
+		// keep the transaction recognized, values of txnCtx are still reachable
+		ctx = &DaoTxnContext{ctx}
+	}
+	return ctx, func(err *error) {
+		for i := len(finishes) - 1; i >= 0; i-- {
+			finishes[i](*err)
+		}
+	}
+}
+
+// invoke executes the statement through the interceptors chain
+func (dao *Dao) invoke(ctx context.Context, stmt *types.Statement, invoker types.Invoker) (*types.Result, error) {
+	for i := len(dao.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := dao.interceptors[i], invoker
+		invoker = func(ctx context.Context, stmt *types.Statement) (*types.Result, error) {
+			return interceptor(ctx, stmt, next)
+		}
+	}
+	return invoker(ctx, stmt)
+}
+
+// exec executes a writing statement
+func (dao *Dao) exec(ctx context.Context, e executor, op types.Operation, table, sqlStr string, args ...interface{}) (*types.Result, error) {
+	stmt := &types.Statement{
+		Operation: op,
+		Table:     table,
+		SQL:       sqlStr,
+		Args:      args,
+	}
+	return dao.invoke(ctx, stmt, func(ctx context.Context, stmt *types.Statement) (*types.Result, error) {
+		result, err := e.ExecContext(ctx, stmt.SQL, stmt.Args...)
+		if err != nil {
+			return nil, err
+		}
+		r := &types.Result{}
+		r.RowsAffected, err = result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if stmt.Operation == types.OperationInsert {
+			if r.LastInsertId, err = result.LastInsertId(); err != nil {
+				dao.logger.Warn("Fetch insert_id failed", err)
+			}
+		}
+		return r, nil
+	})
+}
+
+// query executes a reading statement and invokes fn for every row fetched.
+//	Iteration will be stopped if fn returns an error.
+func (dao *Dao) query(ctx context.Context, e executor, op types.Operation, fn func(rows *sql.Rows) error, table, sqlStr string, args ...interface{}) (*types.Result, error) {
+	stmt := &types.Statement{
+		Operation: op,
+		Table:     table,
+		SQL:       sqlStr,
+		Args:      args,
+	}
+	return dao.invoke(ctx, stmt, func(ctx context.Context, stmt *types.Statement) (*types.Result, error) {
+		rows, err := e.QueryContext(ctx, stmt.SQL, stmt.Args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		r := &types.Result{}
+		for rows.Next() {
+			if err = fn(rows); err != nil {
+				return r, err
+			}
+			r.RowsAffected++
+		}
+		return r, rows.Err()
+	})
+}
+
+// queryRow executes a reading statement and scans the first row into values
+func (dao *Dao) queryRow(ctx context.Context, e executor, op types.Operation, values []interface{}, table, sqlStr string, args ...interface{}) (*types.Result, error) {
+	stmt := &types.Statement{
+		Operation: op,
+		Table:     table,
+		SQL:       sqlStr,
+		Args:      args,
+	}
+	return dao.invoke(ctx, stmt, func(ctx context.Context, stmt *types.Statement) (*types.Result, error) {
+		err := e.QueryRowContext(ctx, stmt.SQL, stmt.Args...).Scan(values...)
+		if err != nil {
+			return nil, err
+		}
+		return &types.Result{RowsAffected: 1}, nil
+	})
+}
@@ -0,0 +1,112 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jasonjoo2010/godao/options"
+	"github.com/jasonjoo2010/godao/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterceptors(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+
+	var traces []string
+	record := func(name string) types.Interceptor {
+		return func(ctx context.Context, stmt *types.Statement, next types.Invoker) (*types.Result, error) {
+			traces = append(traces, name+":"+stmt.Operation.String()+":"+stmt.Table)
+			result, err := next(ctx, stmt)
+			if err == nil {
+				traces = append(traces, name+":done")
+			}
+			return result, err
+		}
+	}
+	rewrite := func(ctx context.Context, stmt *types.Statement, next types.Invoker) (*types.Result, error) {
+		if strings.HasPrefix(stmt.SQL, "delete") {
+			return nil, errors.New("deletion is forbidden")
+		}
+		stmt.SQL = "/* rewritten */ " + stmt.SQL
+		return next(ctx, stmt)
+	}
+	dao := NewDao(Demo{}, db, options.WithInterceptors(record("a"), record("b")), options.WithInterceptors(rewrite))
+
+	mock.ExpectQuery("/* rewritten */ select count(*) from `demo` where `id` > ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"cnt"}).AddRow(3))
+	cnt, err := dao.Count(context.Background(), (&Query{}).Greater("Id", 1).Data())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), cnt)
+	assert.Equal(t, []string{"a:aggregate:demo", "b:aggregate:demo", "b:done", "a:done"}, traces)
+
+	traces = traces[:0]
+	_, err = dao.Delete(context.Background(), 1)
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"a:delete:demo", "b:delete:demo"}, traces)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestCallObservers(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+
+	var traces []string
+	observer := func(ctx context.Context, call *types.Call) (context.Context, func(err error)) {
+		traces = append(traces, call.Method+":"+call.Table)
+		return context.WithValue(ctx, "observed", call.Method), func(err error) {
+			traces = append(traces, call.Method+":done:"+fmt.Sprint(err))
+		}
+	}
+	var callCtx context.Context
+	statements := func(ctx context.Context, stmt *types.Statement, next types.Invoker) (*types.Result, error) {
+		traces = append(traces, stmt.Operation.String()+":"+fmt.Sprint(ctx.Value("observed")))
+		callCtx = ctx
+		return next(ctx, stmt)
+	}
+	dao := NewDao(Demo{}, db, options.WithCallObservers(observer), options.WithInterceptors(statements))
+
+	// nested calls are observed once
+	mock.ExpectQuery("select count(*) from `demo` where `name` = ?").
+		WithArgs("a").
+		WillReturnRows(sqlmock.NewRows([]string{"cnt"}).AddRow(1))
+	_, err := dao.CountBy(context.Background(), "Name", "a")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"CountBy:demo", "aggregate:CountBy", "CountBy:done:<nil>"}, traces)
+
+	// calls of other daos with the context are observed
+	traces = nil
+	other := NewDao(NullableDemo{}, db, options.WithCallObservers(observer))
+	mock.ExpectQuery("select count(*) from `nullable_demo` where `nick` = ?").
+		WithArgs("a").
+		WillReturnRows(sqlmock.NewRows([]string{"cnt"}).AddRow(1))
+	_, err = other.CountBy(callCtx, "Nick", "a")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"CountBy:nullable_demo", "CountBy:done:<nil>"}, traces)
+
+	// transaction is kept
+	traces = nil
+	mock.ExpectBegin()
+	mock.ExpectExec("delete from `demo` where `id` = ?").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	txn, err := dao.Txn(nil)
+	assert.Nil(t, err)
+	_, err = dao.Delete(txn, 1)
+	assert.Nil(t, err)
+	assert.Nil(t, txn.Commit())
+	assert.Equal(t, []string{"Delete:demo", "delete:Delete", "Delete:done:<nil>"}, traces)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,265 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+)
+
+var (
+	errStmtCacheClosed = errors.New("Statement cache is closed")
+	errStmtNotCached   = errors.New("Statement is not cached")
+)
+
+type stmtKey struct {
+	db  *sql.DB
+	sql string
+}
+
+type stmtEntry struct {
+	key     stmtKey
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+// stmtCache holds prepared statements keyed by db and final sql.
+//	Statements evicted are closed after all of their users released them.
+type stmtCache struct {
+	sync.Mutex
+	size    int
+	closed  bool
+	ll      *list.List
+	entries map[stmtKey]*list.Element
+}
+
+func newStmtCache(size int) *stmtCache {
+	return &stmtCache{
+		size:    size,
+		ll:      list.New(),
+		entries: make(map[stmtKey]*list.Element),
+	}
+}
+
+// acquire returns the statement prepared on db and the function to release it after using
+func (c *stmtCache) acquire(ctx context.Context, db *sql.DB, sqlStr string) (*sql.Stmt, func(), error) {
+	key := stmtKey{db, sqlStr}
+	c.Lock()
+	if c.closed {
+		c.Unlock()
+		return nil, nil, errStmtCacheClosed
+	}
+	if elem, ok := c.entries[key]; ok {
+		stmt, release := c.use(elem)
+		c.Unlock()
+		return stmt, release, nil
+	}
+	c.Unlock()
+
+	stmt, err := db.PrepareContext(ctx, sqlStr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if c.closed {
+		stmt.Close()
+		return nil, nil, errStmtCacheClosed
+	}
+	if elem, ok := c.entries[key]; ok {
+		// prepared concurrently
+		stmt.Close()
+		stmt, release := c.use(elem)
+		return stmt, release, nil
+	}
+	elem := c.ll.PushFront(&stmtEntry{key: key, stmt: stmt})
+	c.entries[key] = elem
+	stmt, release := c.use(elem)
+	for c.ll.Len() > c.size {
+		c.evict(c.ll.Back())
+	}
+	return stmt, release, nil
+}
+
+// lookup returns the statement cached without preparing
+func (c *stmtCache) lookup(db *sql.DB, sqlStr string) (*sql.Stmt, func(), bool) {
+	c.Lock()
+	defer c.Unlock()
+	if c.closed {
+		return nil, nil, false
+	}
+	elem, ok := c.entries[stmtKey{db, sqlStr}]
+	if !ok {
+		return nil, nil, false
+	}
+	stmt, release := c.use(elem)
+	return stmt, release, true
+}
+
+func (c *stmtCache) use(elem *list.Element) (*sql.Stmt, func()) {
+	e := elem.Value.(*stmtEntry)
+	e.refs++
+	c.ll.MoveToFront(elem)
+	return e.stmt, func() {
+		c.Lock()
+		e.refs--
+		closing := e.evicted && e.refs == 0
+		c.Unlock()
+		if closing {
+			e.stmt.Close()
+		}
+	}
+}
+
+func (c *stmtCache) evict(elem *list.Element) {
+	e := elem.Value.(*stmtEntry)
+	c.ll.Remove(elem)
+	delete(c.entries, e.key)
+	e.evicted = true
+	if e.refs == 0 {
+		e.stmt.Close()
+	}
+}
+
+// close closes all statements and disables the cache
+func (c *stmtCache) close() {
+	c.Lock()
+	defer c.Unlock()
+	c.closed = true
+	for c.ll.Len() > 0 {
+		c.evict(c.ll.Back())
+	}
+}
+
+// preparedExecutor executes statements prepared through the cache.
+//	In a transaction the pool is never touched because the transaction holds a connection already:
+//	statements cached are bound by Tx.StmtContext, others are prepared by the transaction
+//	and kept in batch, or executed directly without batch.
+//	It falls back to executing directly if preparing failed.
+type preparedExecutor struct {
+	stmts *stmtCache
+	db    *sql.DB
+	tx    *sql.Tx
+
+	// batch keeps statements prepared until closed if it's not nil
+	batch    map[string]*sql.Stmt
+	releases []func()
+}
+
+func (e *preparedExecutor) raw() executor {
+	if e.tx != nil {
+		return e.tx
+	}
+	return e.db
+}
+
+func (e *preparedExecutor) prepare(ctx context.Context, sqlStr string) (*sql.Stmt, func(), error) {
+	if stmt, ok := e.batch[sqlStr]; ok {
+		return stmt, func() {}, nil
+	}
+	if e.tx != nil {
+		return e.prepareTx(ctx, sqlStr)
+	}
+	stmt, release, err := e.stmts.acquire(ctx, e.db, sqlStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if e.batch != nil {
+		e.batch[sqlStr] = stmt
+		e.releases = append(e.releases, release)
+		return stmt, func() {}, nil
+	}
+	return stmt, release, nil
+}
+
+// prepareTx prepares on the connection of transaction.
+//	Statements bound to transaction are closed when the transaction ends
+//	which may be later than rows fetched are consumed.
+func (e *preparedExecutor) prepareTx(ctx context.Context, sqlStr string) (*sql.Stmt, func(), error) {
+	if stmt, release, ok := e.stmts.lookup(e.db, sqlStr); ok {
+		stmt = e.tx.StmtContext(ctx, stmt)
+		if e.batch != nil {
+			e.batch[sqlStr] = stmt
+			e.releases = append(e.releases, release)
+			return stmt, func() {}, nil
+		}
+		return stmt, release, nil
+	}
+	if e.batch == nil {
+		// preparing for single use costs an extra round trip
+		return nil, nil, errStmtNotCached
+	}
+	stmt, err := e.tx.PrepareContext(ctx, sqlStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	e.batch[sqlStr] = stmt
+	return stmt, func() {}, nil
+}
+
+// close releases statements kept in batch
+func (e *preparedExecutor) close() {
+	for _, release := range e.releases {
+		release()
+	}
+	e.batch = nil
+	e.releases = nil
+}
+
+func (e *preparedExecutor) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stmt, release, err := e.prepare(ctx, query)
+	if err != nil {
+		return e.raw().ExecContext(ctx, query, args...)
+	}
+	defer release()
+	return stmt.ExecContext(ctx, args...)
+}
+
+func (e *preparedExecutor) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, release, err := e.prepare(ctx, query)
+	if err != nil {
+		return e.raw().QueryContext(ctx, query, args...)
+	}
+	defer release()
+	return stmt.QueryContext(ctx, args...)
+}
+
+func (e *preparedExecutor) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	stmt, release, err := e.prepare(ctx, query)
+	if err != nil {
+		return e.raw().QueryRowContext(ctx, query, args...)
+	}
+	defer release()
+	return stmt.QueryRowContext(ctx, args...)
+}
+
+// prepared wraps db or tx on db into an executor using the statement cache if it's enabled
+func (dao *Dao) prepared(db *sql.DB, tx *sql.Tx) executor {
+	if dao.stmts == nil || db == nil {
+		if tx != nil {
+			return tx
+		}
+		return db
+	}
+	return &preparedExecutor{
+		stmts: dao.stmts,
+		db:    db,
+		tx:    tx,
+	}
+}
+
+// Close closes statements cached by the dao and its copies.
+//	The databases are not closed.
+func (dao *Dao) Close() error {
+	if dao.stmts != nil {
+		dao.stmts.close()
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jasonjoo2010/godao/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStmtCache(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(Demo{}, db, options.WithStmtCache(1))
+	ctx := context.Background()
+	selectSQL := "select `id` as `Id`, `name` as `Name`, `value` as `Value`, `cnt` as `Cnt`, `created` as `Created` from `demo` where `id` = ? limit 0, 1;"
+	columns := []string{"Id", "Name", "Value", "Cnt", "Created"}
+
+	// prepared once
+	prepared := mock.ExpectPrepare(selectSQL)
+	prepared.ExpectQuery().WithArgs(1).WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "a", "", 1, 0))
+	prepared.ExpectQuery().WithArgs(2).WillReturnRows(sqlmock.NewRows(columns).AddRow(2, "b", "", 1, 0))
+	obj, err := dao.SelectOne(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", obj.(*Demo).Name)
+	obj, err = dao.SelectOne(ctx, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "b", obj.(*Demo).Name)
+
+	// prepared once per batch by the transaction
+	insertSQL := "insert into `demo` (`id`, `name`, `value`, `cnt`, `created`) values (?, ?, ?, ?, ?);"
+	prepared.WillBeClosed()
+	mock.ExpectBegin()
+	inserting := mock.ExpectPrepare(insertSQL)
+	inserting.ExpectExec().WithArgs(int64(0), "c", "", 0, int64(0)).WillReturnResult(sqlmock.NewResult(3, 1))
+	inserting.ExpectExec().WithArgs(int64(0), "d", "", 0, int64(0)).WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectCommit()
+	affected, ids, err := dao.BatchInsert(ctx, []interface{}{&Demo{Name: "c"}, &Demo{Name: "d"}})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), affected)
+	assert.Equal(t, []int64{3, 4}, ids)
+
+	assert.Nil(t, dao.Close())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestStmtCacheTxn(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	// the transaction holds the only connection
+	db.SetMaxOpenConns(1)
+	dao := NewDao(Demo{}, db, options.WithStmtCache(10))
+	ctx := context.Background()
+	selectSQL := "select `id` as `Id`, `name` as `Name`, `value` as `Value`, `cnt` as `Cnt`, `created` as `Created` from `demo` where `id` = ? limit 0, 1;"
+	updateSQL := "update `demo` set `name` = ?, `value` = ?, `cnt` = ?, `created` = ? where `id` = ?"
+	columns := []string{"Id", "Name", "Value", "Cnt", "Created"}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		prepared := mock.ExpectPrepare(selectSQL)
+		prepared.ExpectQuery().WithArgs(1).WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "a", "", 1, 0))
+		_, err := dao.SelectOne(ctx, 1)
+		assert.Nil(t, err)
+
+		// statement cached is bound to the transaction
+		mock.ExpectBegin()
+		prepared.ExpectQuery().WithArgs(1).WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "a", "", 1, 0))
+		mock.ExpectPrepare(updateSQL).
+			ExpectExec().WithArgs("b", "", 1, int64(0), int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		txn, err := dao.Txn(nil)
+		assert.Nil(t, err)
+		_, err = dao.SelectOne(txn, 1)
+		assert.Nil(t, err)
+		affected, err := dao.Update(txn, &Demo{Id: 1, Name: "b", Cnt: 1})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), affected)
+		assert.Nil(t, txn.Txn().Commit())
+
+		// transaction opened by update
+		mock.ExpectBegin()
+		mock.ExpectPrepare(updateSQL).
+			ExpectExec().WithArgs("c", "", 1, int64(0), int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		_, err = dao.Update(ctx, &Demo{Id: 1, Name: "c", Cnt: 1})
+		assert.Nil(t, err)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Blocked on the pool while holding a transaction")
+	}
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
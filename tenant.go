@@ -0,0 +1,40 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	internal_TENANT = "__TENANT__"
+)
+
+var (
+	// ErrNoTenant is returned when operating a tenant scoped model without tenant in context.
+	ErrNoTenant = errors.New("No tenant found in context")
+)
+
+// WithTenant returns a context carrying the tenant which scopes all operations
+//	on models having a field tagged by `dao:"tenant"`.
+func WithTenant(ctx context.Context, tenant interface{}) context.Context {
+	return withValue(ctx, internal_TENANT, tenant)
+}
+
+// TenantOf returns the tenant in context
+func TenantOf(ctx context.Context) (interface{}, bool) {
+	tenant := ctx.Value(internal_TENANT)
+	return tenant, tenant != nil
+}
+
+// tenantOf returns the tenant in context for tenant scoped model
+func (dao *Dao) tenantOf(ctx context.Context) (interface{}, error) {
+	tenant, ok := TenantOf(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+	return tenant, nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package godao
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jasonjoo2010/godao/options"
+	"github.com/jasonjoo2010/godao/sharding"
+	"github.com/jasonjoo2010/godao/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type TenantDemo struct {
+	Id       int64 `dao:"primary;auto_increment"`
+	TenantId int64 `dao:"tenant"`
+	Name     string
+}
+
+func TestTenant(t *testing.T) {
+	db, mock := mockDB(t)
+	defer db.Close()
+	dao := NewDao(TenantDemo{}, db)
+	ctx := WithTenant(context.Background(), int64(7))
+	columns := []string{"Id", "TenantId", "Name"}
+
+	// missing tenant
+	_, err := dao.SelectBy(context.Background(), "Name", "a", 0)
+	assert.Equal(t, ErrNoTenant, err)
+	_, _, err = dao.Insert(context.Background(), &TenantDemo{Name: "a"})
+	assert.Equal(t, ErrNoTenant, err)
+
+	// tenant in object is overwritten
+	mock.ExpectBegin()
+	mock.ExpectExec("insert into `tenant_demo` (`id`, `tenant_id`, `name`) values (?, ?, ?);").
+		WithArgs(int64(0), int64(7), "a").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	_, _, err = dao.Insert(ctx, &TenantDemo{TenantId: 8, Name: "a"})
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("select `id` as `Id`, `tenant_id` as `TenantId`, `name` as `Name` from `tenant_demo` where `tenant_id` = ? and `name` = ?;").
+		WithArgs(int64(7), "a").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 7, "a"))
+	list, err := dao.SelectBy(ctx, "Name", "a", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(list))
+
+	// conditions joined by OR are wrapped
+	mock.ExpectQuery("select count(*) from `tenant_demo` where `tenant_id` = ? and (`name` = ? or `id` = ?)").
+		WithArgs(int64(7), "a", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"cnt"}).AddRow(1))
+	cnt, err := dao.Count(ctx, (&Query{}).Equal("Name", "a").Equal("Id", 1).Or().Data())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("update `tenant_demo` set `name` = ? where `id` = ? and `tenant_id` = ?").
+		WithArgs("b", int64(1), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	affected, err := dao.Update(ctx, &TenantDemo{Id: 1, TenantId: 8, Name: "b"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	mock.ExpectExec("update `tenant_demo` set `name` = ? where `tenant_id` = ? and `id` = ?").
+		WithArgs("c", int64(7), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = dao.UpdateBy(ctx, (&Query{}).Equal("Id", 1).Data(), &types.UpdateEntry{Field: "Name", Value: "c"})
+	assert.Nil(t, err)
+
+	mock.ExpectExec("delete from `tenant_demo` where `tenant_id` = ? and `id` = ?").
+		WithArgs(int64(7), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	affected, err = dao.Delete(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestTenantSharding(t *testing.T) {
+	db0, mock0 := mockDB(t)
+	defer db0.Close()
+	db1, mock1 := mockDB(t)
+	defer db1.Close()
+	dao := NewDao(TenantDemo{}, db0, options.WithSharding(sharding.NewModulo("TenantId", "tenant_demo_%d", 2, db0, db1)))
+	ctx := WithTenant(context.Background(), int64(7))
+
+	// routed by the tenant in context rather than the stale field
+	mock1.ExpectBegin()
+	mock1.ExpectExec("insert into `tenant_demo_1` (`id`, `tenant_id`, `name`) values (?, ?, ?);").
+		WithArgs(int64(0), int64(7), "a").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock1.ExpectCommit()
+	_, _, err := dao.Insert(ctx, &TenantDemo{TenantId: 4, Name: "a"})
+	assert.Nil(t, err)
+
+	mock1.ExpectBegin()
+	mock1.ExpectExec("update `tenant_demo_1` set `name` = ? where `id` = ? and `tenant_id` = ?").
+		WithArgs("b", int64(1), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock1.ExpectCommit()
+	_, err = dao.Update(ctx, &TenantDemo{Id: 1, Name: "b"})
+	assert.Nil(t, err)
+
+	assert.Nil(t, mock0.ExpectationsWereMet())
+	assert.Nil(t, mock1.ExpectationsWereMet())
+}
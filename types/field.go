@@ -17,5 +17,23 @@ type ModelField struct {
 	Primary bool
 	// Whether is auto increment
 	AutoIncrement bool
-	Type          reflect.Type
+	// Whether is the version column used in optimistic locking
+	Version bool
+	// Whether is the tenant column scoping all operations
+	Tenant bool
+	// Whether NULL is read as zero value and zero value is written as NULL
+	NullZero bool
+	Type     reflect.Type
+
+	// schema
+	// Size of string or bytes column
+	Size int
+	// Whether the column accepts null
+	Nullable bool
+	// Default value in definition if HasDefault
+	Default    string
+	HasDefault bool
+	// Names of indexes / unique keys the column belongs to
+	Indexes []string
+	Uniques []string
 }
@@ -0,0 +1,33 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package types
+
+import (
+	"context"
+	"time"
+)
+
+// StatementLog represents an executed statement.
+type StatementLog struct {
+	Operation Operation
+	Table     string
+	SQL       string
+	ArgsCount int
+	// Args is nil unless logging arguments is enabled, and may be redacted.
+	Args         []interface{}
+	Duration     time.Duration
+	RowsAffected int64
+	Err          error
+	// Whether duration exceeds the slow threshold
+	Slow bool
+}
+
+// Logger logs statements and unexpected situations in dao.
+type Logger interface {
+	// Statement is invoked after every statement executed.
+	Statement(ctx context.Context, log *StatementLog)
+	// Warn logs unexpected situations which are ignored.
+	Warn(msg string, err error)
+}
@@ -0,0 +1,20 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package types
+
+import "time"
+
+// MetricsCollector collects metrics of every statement executed.
+type MetricsCollector interface {
+	// Observe is invoked after statement executed.
+	//	rows is the rows affected when writing or rows fetched when reading.
+	Observe(op Operation, table string, duration time.Duration, rows int64, err error)
+}
+
+// NoopMetricsCollector collects nothing which is the default collector.
+type NoopMetricsCollector struct{}
+
+func (NoopMetricsCollector) Observe(op Operation, table string, duration time.Duration, rows int64, err error) {
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 The GoDao Authors. All rights reserved.
+// Use of this source code is governed by BSD
+// license that can be found in the LICENSE file.
+
+package types
+
+import "context"
+
+type Operation int
+
+const (
+	_ Operation = iota
+	OperationSelect
+	OperationInsert
+	OperationUpdate
+	OperationDelete
+	OperationAggregate
+	OperationDDL
+)
+
+func (o Operation) String() string {
+	switch o {
+	case OperationSelect:
+		return "select"
+	case OperationInsert:
+		return "insert"
+	case OperationUpdate:
+		return "update"
+	case OperationDelete:
+		return "delete"
+	case OperationAggregate:
+		return "aggregate"
+	case OperationDDL:
+		return "ddl"
+	}
+	return ""
+}
+
+// Statement represents a statement going to be executed by dao.
+//	SQL and Args can be rewritten by interceptors before passing to next.
+type Statement struct {
+	Operation Operation
+	Table     string
+	SQL       string
+	Args      []interface{}
+}
+
+// Result represents the outcome of an executed statement.
+type Result struct {
+	// Rows affected when writing or rows fetched when reading
+	RowsAffected int64
+	// Last inserted id when inserting
+	LastInsertId int64
+}
+
+// Invoker executes the statement actually.
+type Invoker func(ctx context.Context, stmt *Statement) (*Result, error)
+
+// Interceptor wraps the execution of every statement.
+//	It should invoke next to continue or return an error directly to stop the execution.
+type Interceptor func(ctx context.Context, stmt *Statement, next Invoker) (*Result, error)
+
+// Call represents a public method of dao being invoked, e.g. "Select" or "BatchUpdate".
+type Call struct {
+	Method string
+	Table  string
+}
+
+// CallObserver is notified when a public method of dao is invoked.
+//	The context returned is used through the call, including statements executed,
+//	and finish is invoked with the error when the call returns.
+//	Nested calls, e.g. SelectOne calling Select, are observed once as the outermost one.
+type CallObserver func(ctx context.Context, call *Call) (context.Context, func(err error))
@@ -10,11 +10,21 @@ import "fmt"
 //	Simple value updating. Value should be filled and it will be taken as a raw value.
 //	Expression updating. Value should be omitted and Expr should be set. It will not be parsed as a whole value and you can use functions, reference other fields.
 //	It's DANGEROUS when using Expr method. Possible injections and data damagement could occur.
+//	Set Null to update the column to NULL.
 type UpdateEntry struct {
 	Field string
 	Value interface{}
 	Expr  string
 	Args  []interface{} // bind to expr
+	Null  bool
+}
+
+// NewNull sets the field to NULL
+func NewNull(field string) *UpdateEntry {
+	return &UpdateEntry{
+		Field: field,
+		Null:  true,
+	}
 }
 
 func NewIncrease(field string, step int64) *UpdateEntry {